@@ -0,0 +1,115 @@
+package slogx
+
+import (
+	"container/list"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BlobRef is a handle to data kept by Blob. It is logged as
+// {"$blob": id, "size": n}, and viewers fetch the data from /blob/{id}.
+type BlobRef struct {
+	ID   string
+	Size int
+}
+
+var blobRefType = reflect.TypeOf(BlobRef{})
+
+const (
+	defaultBlobTTL  = 10 * time.Minute
+	defaultMaxBlobs = 64
+)
+
+func (c *Config) blobTTL() time.Duration {
+	if c.BlobTTL > 0 {
+		return c.BlobTTL
+	}
+	return defaultBlobTTL
+}
+
+func (c *Config) maxBlobs() int {
+	if c.MaxBlobs > 0 {
+		return c.MaxBlobs
+	}
+	return defaultMaxBlobs
+}
+
+// blobCache holds blobs until they expire or, once full, until they are
+// the least recently used.
+type blobCache struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   list.List // most recently used first
+}
+
+type storedBlob struct {
+	id      string
+	data    []byte
+	expires time.Time
+}
+
+// Blob keeps a copy of data, such as a full response body, and returns a
+// handle to log in its place, so the data is only sent to viewers that ask
+// for it. Blobs are served for Config.BlobTTL (default 10m); at most
+// Config.MaxBlobs (default 64) are kept, dropping the least recently used.
+func Blob(data []byte) BlobRef {
+	s := getInstance()
+	ref := BlobRef{ID: generateID(), Size: len(data)}
+	s.blobs.put(&storedBlob{
+		id:      ref.ID,
+		data:    append([]byte(nil), data...),
+		expires: time.Now().Add(s.config.blobTTL()),
+	}, s.config.maxBlobs())
+	return ref
+}
+
+func (c *blobCache) put(b *storedBlob, max int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]*list.Element)
+	}
+	c.entries[b.id] = c.order.PushFront(b)
+	for c.order.Len() > max {
+		c.remove(c.order.Back())
+	}
+}
+
+// get returns a blob that hasn't expired, marking it recently used.
+func (c *blobCache) get(id string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[id]
+	if !ok {
+		return nil, false
+	}
+	b := el.Value.(*storedBlob)
+	if time.Now().After(b.expires) {
+		c.remove(el)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return b.data, true
+}
+
+func (c *blobCache) remove(el *list.Element) {
+	delete(c.entries, el.Value.(*storedBlob).id)
+	c.order.Remove(el)
+}
+
+// handleBlob serves a blob logged with Blob, or 404 once it has expired.
+func (s *SlogX) handleBlob(w http.ResponseWriter, r *http.Request) {
+	data, ok := s.blobs.get(strings.TrimPrefix(r.URL.Path, "/blob/"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", http.DetectContentType(data))
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Write(data)
+}
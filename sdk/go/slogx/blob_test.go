@@ -0,0 +1,63 @@
+package slogx
+
+import (
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func fetchBlob(t *testing.T, url string) (int, string) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestBlob_FetchedByReference(t *testing.T) {
+	s, srv := startTestServer(t)
+	s.config.BlobTTL = 100 * time.Millisecond
+	conn := dialClient(t, wsURL(srv))
+	waitForClients(t, s, 1)
+
+	body := []byte(`{"items": [1, 2, 3]}`)
+	Info("response", Blob(body))
+
+	entry := readEntry(t, conn)
+	ref := entry.Args[1].(map[string]interface{})
+	id, _ := ref["$blob"].(string)
+	if id == "" || ref["size"] != float64(len(body)) {
+		t.Fatalf("expected a blob reference in place of the data, got %v", ref)
+	}
+
+	status, got := fetchBlob(t, srv.URL+"/blob/"+id)
+	if status != http.StatusOK || got != string(body) {
+		t.Errorf("expected the blob, got %d %q", status, got)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if status, _ := fetchBlob(t, srv.URL+"/blob/"+id); status != http.StatusNotFound {
+		t.Errorf("expected an expired blob to be gone, got %d", status)
+	}
+}
+
+func TestBlob_EvictsLeastRecentlyUsed(t *testing.T) {
+	s, srv := startTestServer(t)
+	s.config.MaxBlobs = 2
+
+	first := Blob([]byte("first"))
+	second := Blob([]byte("second"))
+	fetchBlob(t, srv.URL+"/blob/"+first.ID)
+	Blob([]byte("third"))
+
+	if status, _ := fetchBlob(t, srv.URL+"/blob/"+second.ID); status != http.StatusNotFound {
+		t.Errorf("expected the least recently used blob evicted, got %d", status)
+	}
+	if status, got := fetchBlob(t, srv.URL+"/blob/"+first.ID); status != http.StatusOK || got != "first" {
+		t.Errorf("expected the recently fetched blob kept, got %d %q", status, got)
+	}
+}
@@ -0,0 +1,77 @@
+package slogx
+
+import (
+	"context"
+	"sync"
+)
+
+// burst holds back the entries of a BeginBurst scope until an error is
+// logged in it.
+type burst struct {
+	mu      sync.Mutex
+	size    int
+	entries []LogEntry
+	ended   bool
+}
+
+type burstKey struct{}
+
+// BeginBurst returns a context that captures entries quietly: entries below
+// ERROR logged with LogCtx and the returned context are held back, and only
+// reach the sinks, in order and ahead of it, when an ERROR is logged with
+// the same context. This gives failures their full context without normal
+// runs being noisy. At most Config.BurstSize entries are held, dropping the
+// oldest. Call EndBurst when the scope is done.
+func BeginBurst(ctx context.Context) context.Context {
+	return context.WithValue(ctx, burstKey{}, &burst{size: getInstance().config.burstSize()})
+}
+
+// EndBurst closes a BeginBurst scope, discarding the entries still held
+// and returning how many there were. Entries logged with ctx afterwards are
+// delivered right away.
+func EndBurst(ctx context.Context) (discarded int) {
+	b := burstFrom(ctx)
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	discarded = len(b.entries)
+	b.entries = nil
+	b.ended = true
+	return discarded
+}
+
+func burstFrom(ctx context.Context) *burst {
+	if ctx == nil {
+		return nil
+	}
+	b, _ := ctx.Value(burstKey{}).(*burst)
+	return b
+}
+
+// hold keeps a copy of entry, reporting false once the scope has ended. The
+// copy has its own args and metadata, since the entry's are pooled.
+func (b *burst) hold(entry LogEntry) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ended {
+		return false
+	}
+
+	entry = detachEntry(entry)
+	if len(b.entries) == b.size {
+		b.entries = append(b.entries[:0], b.entries[1:]...)
+	}
+	b.entries = append(b.entries, entry)
+	return true
+}
+
+// take returns the held entries and starts holding anew.
+func (b *burst) take() []LogEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	entries := b.entries
+	b.entries = nil
+	return entries
+}
@@ -0,0 +1,58 @@
+package slogx
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBurst_FlushedByError(t *testing.T) {
+	read := initCapture(t, Config{})
+
+	ctx := BeginBurst(context.Background())
+	LogCtx(ctx, INFO, "connecting", map[string]interface{}{"host": "db"})
+	LogCtx(ctx, DEBUG, "retrying")
+	Info("outside the scope")
+
+	if entries := read(); len(entries) != 1 || entries[0].Args[0] != "outside the scope" {
+		t.Fatalf("expected only the unscoped entry before an error, got %+v", entries)
+	}
+
+	LogCtx(ctx, ERROR, "connection failed")
+	LogCtx(ctx, INFO, "after the error")
+
+	entries := read()
+	want := []string{"outside the scope", "connecting", "retrying", "connection failed"}
+	if len(entries) != len(want) {
+		t.Fatalf("expected %d entries, got %d", len(want), len(entries))
+	}
+	for i, msg := range want {
+		if entries[i].Args[0] != msg {
+			t.Errorf("entry %d: expected %q, got %v", i, msg, entries[i].Args[0])
+		}
+	}
+	if host := entries[1].Args[1].(map[string]interface{})["host"]; host != "db" || entries[1].Metadata["file"] != "burst_test.go" {
+		t.Errorf("expected the held entry intact, got %+v", entries[1])
+	}
+
+	if n := EndBurst(ctx); n != 1 {
+		t.Errorf("expected the entry after the error to be discarded, got %d", n)
+	}
+}
+
+func TestBurst_DiscardedWithoutError(t *testing.T) {
+	read := initCapture(t, Config{BurstSize: 2})
+
+	ctx := BeginBurst(context.Background())
+	for i := 0; i < 5; i++ {
+		LogCtx(ctx, INFO, "step", i)
+	}
+	if n := EndBurst(ctx); n != 2 {
+		t.Errorf("expected BurstSize entries held, got %d", n)
+	}
+	LogCtx(ctx, INFO, "after the scope")
+
+	entries := read()
+	if len(entries) != 1 || entries[0].Args[0] != "after the scope" {
+		t.Errorf("expected only the entry after EndBurst, got %+v", entries)
+	}
+}
@@ -0,0 +1,132 @@
+package slogx
+
+import "sync"
+
+// Captured holds the entries logged while a Capture is active.
+type Captured struct {
+	s       *SlogX
+	mu      sync.Mutex
+	entries []LogEntry
+}
+
+// TestingT is the part of *testing.T that AssertMaxLevel uses.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// Capture keeps every entry that passes MinLevel in memory until the
+// returned stop function is called, so tests can inspect what was logged,
+// e.g.
+//
+//	logs, stop := slogx.Capture()
+//	defer stop()
+//	...
+//	logs.AssertMaxLevel(t, slogx.WARN)
+//
+// Entries are captured whether or not any other sink is configured.
+func Capture() (c *Captured, stop func()) {
+	s := getInstance()
+	c = &Captured{s: s}
+
+	s.captureMu.Lock()
+	if s.captures == nil {
+		s.captures = make(map[*Captured]bool)
+	}
+	s.captures[c] = true
+	s.captureMu.Unlock()
+
+	return c, func() {
+		s.captureMu.Lock()
+		delete(s.captures, c)
+		s.captureMu.Unlock()
+	}
+}
+
+// Entries returns the entries captured so far, in the order they were
+// logged.
+func (c *Captured) Entries() []LogEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]LogEntry(nil), c.entries...)
+}
+
+// HighestLevel returns the most severe level captured, or "" when nothing
+// was.
+func (c *Captured) HighestLevel() LogLevel {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var highest LogLevel
+	for _, entry := range c.entries {
+		if highest == "" || levelRank[entry.Level] > levelRank[highest] {
+			highest = entry.Level
+		}
+	}
+	return highest
+}
+
+// AssertMaxLevel fails t when an entry above max was captured, reporting
+// how many there were and where the first was logged.
+func (c *Captured) AssertMaxLevel(t TestingT, max LogLevel) {
+	t.Helper()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var first *LogEntry
+	above := 0
+	for i := range c.entries {
+		if levelRank[c.entries[i].Level] > levelRank[max] {
+			if first == nil {
+				first = &c.entries[i]
+			}
+			above++
+		}
+	}
+	if first == nil {
+		return
+	}
+
+	var msg interface{}
+	if len(first.Args) > 0 {
+		msg = first.Args[0]
+	} else {
+		msg = first.Message
+	}
+	t.Errorf("slogx: %d entries above %s, first %s %v at %v:%v",
+		above, max, first.Level, msg, first.Metadata[c.s.metaKey("file")], first.Metadata[c.s.metaKey("line")])
+}
+
+// capturing reports whether any Capture is active.
+func (s *SlogX) capturing() bool {
+	s.captureMu.Lock()
+	defer s.captureMu.Unlock()
+	return len(s.captures) > 0
+}
+
+// capture hands a copy of entry to every active Capture.
+func (s *SlogX) capture(entry LogEntry) {
+	s.captureMu.Lock()
+	defer s.captureMu.Unlock()
+	if len(s.captures) == 0 {
+		return
+	}
+
+	entry = detachEntry(entry)
+	for c := range s.captures {
+		c.mu.Lock()
+		c.entries = append(c.entries, entry)
+		c.mu.Unlock()
+	}
+}
+
+// detachEntry copies an entry's args and metadata, which are pooled and
+// reused once the entry has been emitted, so the copy can be kept.
+func detachEntry(entry LogEntry) LogEntry {
+	entry.Args = append([]interface{}(nil), entry.Args...)
+	metadata := make(map[string]interface{}, len(entry.Metadata))
+	for k, v := range entry.Metadata {
+		metadata[k] = v
+	}
+	entry.Metadata = metadata
+	return entry
+}
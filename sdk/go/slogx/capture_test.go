@@ -0,0 +1,83 @@
+package slogx
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// fakeT records AssertMaxLevel failures instead of failing the test.
+type fakeT struct {
+	errors []string
+}
+
+func (*fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func TestCapture_AssertMaxLevelPasses(t *testing.T) {
+	resetInstance()
+	t.Cleanup(resetInstance)
+
+	logs, stop := Capture()
+	defer stop()
+	Debug("loading")
+	Info("ready", map[string]interface{}{"port": 8080})
+
+	ft := &fakeT{}
+	logs.AssertMaxLevel(ft, WARN)
+	if len(ft.errors) != 0 {
+		t.Errorf("expected no failure with only INFO logged, got %v", ft.errors)
+	}
+	if logs.HighestLevel() != INFO || len(logs.Entries()) != 2 {
+		t.Errorf("expected 2 entries up to INFO, got %d up to %q", len(logs.Entries()), logs.HighestLevel())
+	}
+	if port := logs.Entries()[1].Args[1].(map[string]interface{})["port"]; port != 8080 {
+		t.Errorf("expected captured args kept intact, got %v", port)
+	}
+}
+
+func TestCapture_AssertMaxLevelFails(t *testing.T) {
+	resetInstance()
+	t.Cleanup(resetInstance)
+
+	logs, stop := Capture()
+	Info("starting")
+	Error("migration failed")
+	Warn("retrying")
+	Error("migration failed again")
+	stop()
+	Error("after stop")
+
+	ft := &fakeT{}
+	logs.AssertMaxLevel(ft, WARN)
+	if len(ft.errors) != 1 {
+		t.Fatalf("expected one failure, got %v", ft.errors)
+	}
+	if msg := ft.errors[0]; !strings.Contains(msg, "2 entries above WARN") || !strings.Contains(msg, "migration failed at capture_test.go:") {
+		t.Errorf("expected the count and first offending entry, got %q", msg)
+	}
+	if logs.HighestLevel() != ERROR || len(logs.Entries()) != 4 {
+		t.Errorf("expected 4 entries up to ERROR, got %d up to %q", len(logs.Entries()), logs.HighestLevel())
+	}
+
+	if empty := (&Captured{}); empty.HighestLevel() != "" {
+		t.Errorf("expected no level without entries, got %q", empty.HighestLevel())
+	}
+}
+
+func TestCapture_AssertMaxLevelMetadataPrefix(t *testing.T) {
+	initCapture(t, Config{MetadataPrefix: "slogx_"})
+
+	logs, stop := Capture()
+	defer stop()
+	Error("prefixed failure")
+
+	ft := &fakeT{}
+	logs.AssertMaxLevel(ft, WARN)
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "prefixed failure at capture_test.go:") {
+		t.Errorf("expected the prefixed file and line reported, got %v", ft.errors)
+	}
+}
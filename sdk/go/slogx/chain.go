@@ -0,0 +1,44 @@
+package slogx
+
+import (
+	"context"
+	"sync"
+)
+
+// chain remembers the id of the last entry logged in a WithChain scope.
+type chain struct {
+	mu   sync.Mutex
+	last string
+}
+
+type chainKey struct{}
+
+// WithChain returns a context whose entries, logged with LogCtx, link
+// back to one another: each gets the id of the entry logged before it with
+// the same context as its ParentID, so a viewer can follow one request's
+// trail. Entries logged with other contexts, including concurrent
+// requests, don't join the chain. The first entry has no ParentID.
+func WithChain(ctx context.Context) context.Context {
+	return context.WithValue(ctx, chainKey{}, &chain{})
+}
+
+func chainFrom(ctx context.Context) *chain {
+	if ctx == nil {
+		return nil
+	}
+	c, _ := ctx.Value(chainKey{}).(*chain)
+	return c
+}
+
+// linkTo sets entry's ParentID to the chain's latest entry and makes entry
+// the latest. It is called only for entries that are emitted, so held,
+// discarded or collapsed ones never become a parent. A nil chain does
+// nothing.
+func (c *chain) linkTo(entry *LogEntry) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.ParentID, c.last = c.last, entry.ID
+}
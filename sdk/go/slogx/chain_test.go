@@ -0,0 +1,117 @@
+package slogx
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestWithChain_LinksEntriesInScope(t *testing.T) {
+	read := initCapture(t, Config{})
+
+	orderCtx := WithChain(context.Background())
+	refundCtx := WithChain(context.Background())
+	LogCtx(orderCtx, INFO, "order received")
+	LogCtx(refundCtx, INFO, "refund requested")
+	LogCtx(orderCtx, INFO, "payment captured")
+	Info("unrelated")
+	LogCtx(orderCtx, INFO, "order shipped")
+	LogCtx(context.Background(), INFO, "no chain")
+
+	entries := read()
+	byMsg := make(map[string]LogEntry)
+	for _, entry := range entries {
+		byMsg[entry.Args[0].(string)] = entry
+	}
+
+	if parent := byMsg["order received"].ParentID; parent != "" {
+		t.Errorf("expected the first entry to start the chain, got parent %q", parent)
+	}
+	if byMsg["payment captured"].ParentID != byMsg["order received"].ID ||
+		byMsg["order shipped"].ParentID != byMsg["payment captured"].ID {
+		t.Errorf("expected each order entry to point at the previous one, got %+v", entries)
+	}
+	for _, msg := range []string{"refund requested", "unrelated", "no chain"} {
+		if parent := byMsg[msg].ParentID; parent != "" {
+			t.Errorf("expected %q outside the order chain, got parent %q", msg, parent)
+		}
+	}
+}
+
+func TestWithChain_ConcurrentScopes(t *testing.T) {
+	read := initCapture(t, Config{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			ctx := WithChain(context.Background())
+			for step := 0; step < 25; step++ {
+				LogCtx(ctx, INFO, "step", worker)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	worker := make(map[string]interface{})
+	children := make(map[string]int)
+	for _, entry := range read() {
+		worker[entry.ID] = entry.Args[1]
+		if entry.ParentID != "" {
+			children[entry.ParentID]++
+		}
+	}
+	for _, entry := range read() {
+		if entry.ParentID != "" && worker[entry.ParentID] != entry.Args[1] {
+			t.Fatalf("expected chains to stay within one worker, got %v linked to %v", entry.Args[1], worker[entry.ParentID])
+		}
+	}
+	if len(children) != 4*24 {
+		t.Errorf("expected 4 unbroken chains of 25, got %d linked parents", len(children))
+	}
+}
+
+func TestWithChain_LinksOnlyEmittedEntries(t *testing.T) {
+	read := initCapture(t, Config{CollapseRepeats: true})
+
+	ctx := WithChain(context.Background())
+	LogCtx(ctx, INFO, "first")
+
+	discarded := BeginBurst(ctx)
+	LogCtx(discarded, INFO, "discarded")
+	EndBurst(discarded)
+	LogCtx(ctx, INFO, "after discard")
+
+	flushed := BeginBurst(ctx)
+	LogCtx(flushed, INFO, "held")
+	LogCtx(flushed, ERROR, "failed")
+	EndBurst(flushed)
+
+	for i := 0; i < 2; i++ {
+		LogCtx(ctx, INFO, "tick")
+	}
+	LogCtx(ctx, INFO, "done")
+
+	byMsg := make(map[string]LogEntry)
+	for _, entry := range read() {
+		if msg, ok := entry.Args[0].(string); ok {
+			byMsg[msg] = entry
+		}
+	}
+	if _, ok := byMsg["discarded"]; ok {
+		t.Fatal("expected the discarded entry never emitted")
+	}
+	links := [][2]string{
+		{"after discard", "first"},
+		{"held", "after discard"},
+		{"failed", "held"},
+		{"tick", "failed"},
+		{"done", "tick"},
+	}
+	for _, link := range links {
+		if got := byMsg[link[0]].ParentID; got != byMsg[link[1]].ID {
+			t.Errorf("expected %q to point at %q, got parent %q", link[0], link[1], got)
+		}
+	}
+}
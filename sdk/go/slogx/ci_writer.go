@@ -22,10 +22,26 @@ type CIWriter struct {
 	closed      bool
 	flushTicker *time.Ticker
 	done        chan bool
+	// pretty indents each entry and separates entries with a blank line.
+	pretty bool
 }
 
 // NewCIWriter creates a new CIWriter instance.
 func NewCIWriter(filePath string, maxEntries int) *CIWriter {
+	return newCIWriter(filePath, maxEntries, defaultFlushInterval, false)
+}
+
+// defaultFlushInterval bounds how long a buffered entry waits to reach the
+// file when Config.FlushInterval is unset.
+const defaultFlushInterval = 500 * time.Millisecond
+
+// newCIWriter is NewCIWriter with a flush interval: buffered entries are
+// written at least this often, however few there are. With pretty set,
+// entries are indented as for Config.PrettyJSON.
+func newCIWriter(filePath string, maxEntries int, flushInterval time.Duration, pretty bool) *CIWriter {
+	if flushInterval <= 0 {
+		flushInterval = defaultFlushInterval
+	}
 	if maxEntries <= 0 {
 		maxEntries = 10000
 	}
@@ -43,8 +59,9 @@ func NewCIWriter(filePath string, maxEntries int) *CIWriter {
 		filePath:    filePath,
 		maxEntries:  maxEntries,
 		buffer:      make([]string, 0),
-		flushTicker: time.NewTicker(500 * time.Millisecond),
+		flushTicker: time.NewTicker(flushInterval),
 		done:        make(chan bool),
+		pretty:      pretty,
 	}
 
 	go w.flushLoop()
@@ -66,13 +83,22 @@ func (w *CIWriter) flushLoop() {
 
 // Write adds a log entry to the buffer.
 func (w *CIWriter) Write(entry interface{}) {
+	// Marshal before locking so a panicking marshaler can't leave the
+	// buffer locked.
+	var bytes []byte
+	var err error
+	if w.pretty {
+		bytes, err = json.MarshalIndent(entry, "", prettyIndent)
+	} else {
+		bytes, err = json.Marshal(entry)
+	}
+
 	w.bufferMu.Lock()
 	if w.closed {
 		w.bufferMu.Unlock()
 		return
 	}
 
-	bytes, err := json.Marshal(entry)
 	if err == nil {
 		w.buffer = append(w.buffer, string(bytes))
 		w.entryCount++
@@ -94,7 +120,8 @@ func (w *CIWriter) Flush() {
 		return
 	}
 
-	content := strings.Join(w.buffer, "\n") + "\n"
+	sep := w.separator()
+	content := strings.Join(w.buffer, sep) + sep
 	w.buffer = make([]string, 0)
 	w.bufferMu.Unlock()
 
@@ -121,18 +148,28 @@ func (w *CIWriter) enforceRollingWindow() {
 		return
 	}
 
-	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
-	if len(lines) <= w.maxEntries {
+	sep := w.separator()
+	entries := strings.Split(strings.TrimSpace(string(content)), sep)
+	if len(entries) <= w.maxEntries {
 		return
 	}
 
 	// Keep last maxEntries
-	trimmed := lines[len(lines)-w.maxEntries:]
-	newContent := strings.Join(trimmed, "\n") + "\n"
+	trimmed := entries[len(entries)-w.maxEntries:]
+	newContent := strings.Join(trimmed, sep) + sep
 
 	ioutil.WriteFile(w.filePath, []byte(newContent), 0644)
 }
 
+// separator ends each entry in the file. Indented JSON never contains a
+// blank line, so one marks where the next pretty entry starts.
+func (w *CIWriter) separator() string {
+	if w.pretty {
+		return "\n\n"
+	}
+	return "\n"
+}
+
 // Close flushes and stops the writer.
 func (w *CIWriter) Close() {
 	w.bufferMu.Lock()
@@ -1,6 +1,7 @@
 package slogx
 
 import (
+	"bytes"
 	"encoding/json"
 	"io/ioutil"
 	"os"
@@ -168,3 +169,70 @@ func TestCIWriter_TimerFlush(t *testing.T) {
 	
 	writer.Close()
 }
+
+func TestCIWriter_FlushIntervalBoundsLatency(t *testing.T) {
+	resetInstance()
+	t.Cleanup(resetInstance)
+
+	filePath := filepath.Join(t.TempDir(), "trickle.ndjson")
+	ciMode := true
+	Init(Config{IsDev: true, CIMode: &ciMode, LogFilePath: filePath, FlushInterval: 50 * time.Millisecond})
+
+	start := time.Now()
+	Info("lone entry")
+
+	// Nothing else is logged and Flush is never called.
+	for {
+		content, _ := ioutil.ReadFile(filePath)
+		if strings.Contains(string(content), "lone entry") {
+			break
+		}
+		if time.Since(start) > 400*time.Millisecond {
+			t.Fatal("expected the entry written within the flush interval")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestCIWriter_PrettyJSON(t *testing.T) {
+	for _, pretty := range []bool{false, true} {
+		resetInstance()
+		filePath := filepath.Join(t.TempDir(), "pretty.ndjson")
+		ciMode := true
+		Init(Config{IsDev: true, CIMode: &ciMode, LogFilePath: filePath, MaxEntries: 2, PrettyJSON: pretty})
+		captured, stop := Capture()
+
+		Info("dropped by the rolling window")
+		Info("request", map[string]interface{}{"user": map[string]interface{}{"id": 7}})
+		Warn("slow", []int{1, 2})
+		stop()
+		getInstance().ciWriter.Flush()
+
+		content, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sep := "\n"
+		if pretty {
+			sep = "\n\n"
+		}
+		chunks := strings.Split(strings.TrimSuffix(string(content), sep), sep)
+		if len(chunks) != 2 {
+			t.Fatalf("pretty=%v: expected 2 entries after trimming, got %d in %q", pretty, len(chunks), content)
+		}
+		for i, chunk := range chunks {
+			if multiline := strings.Contains(chunk, "\n"); multiline != pretty {
+				t.Errorf("pretty=%v: expected multiline %v, got %q", pretty, pretty, chunk)
+			}
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, []byte(chunk)); err != nil {
+				t.Fatalf("pretty=%v: expected valid JSON, got %q: %v", pretty, chunk, err)
+			}
+			want, _ := json.Marshal(captured.Entries()[i+1])
+			if compact.String() != string(want) {
+				t.Errorf("pretty=%v: expected the entry to parse back unchanged, got\n%s\nwant\n%s", pretty, compact.String(), want)
+			}
+		}
+	}
+	resetInstance()
+}
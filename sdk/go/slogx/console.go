@@ -0,0 +1,152 @@
+package slogx
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// ConsoleFormat selects how entries are mirrored to Config.Console.
+type ConsoleFormat string
+
+const (
+	// ConsoleJSON writes each entry as a single line of JSON, or indented
+	// and followed by a blank line with Config.PrettyJSON.
+	ConsoleJSON ConsoleFormat = "JSON"
+	// ConsoleText writes a terse `HH:MM:SS LEVEL msg key=value` line.
+	ConsoleText ConsoleFormat = "Text"
+	// ConsoleLogfmt writes a logfmt line for log pipelines that ingest it:
+	// `time=… level=INFO msg="request done" status=200`.
+	ConsoleLogfmt ConsoleFormat = "Logfmt"
+)
+
+const ansiReset = "\x1b[0m"
+
+var levelColors = map[LogLevel]string{
+	TRACE: "\x1b[90m",
+	DEBUG: "\x1b[36m",
+	INFO:  "\x1b[32m",
+	WARN:  "\x1b[33m",
+	ERROR: "\x1b[31m",
+}
+
+// isTerminal reports whether w is an interactive terminal. Writers that wrap
+// a terminal can opt in by implementing IsTerminal.
+func isTerminal(w io.Writer) bool {
+	if t, ok := w.(interface{ IsTerminal() bool }); ok {
+		return t.IsTerminal()
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// writeConsole mirrors an entry to the configured console writer.
+func (s *SlogX) writeConsole(entry LogEntry) {
+	w := s.config.Console
+	if w == nil {
+		return
+	}
+
+	s.consoleMu.Lock()
+	defer s.consoleMu.Unlock()
+
+	switch s.config.ConsoleFormat {
+	case ConsoleText:
+		io.WriteString(w, formatText(entry, isTerminal(w)))
+		return
+	case ConsoleLogfmt:
+		io.WriteString(w, formatLogfmt(entry))
+		return
+	}
+
+	if s.config.PrettyJSON {
+		marshalPooledIndent(s.formatted(entry), &s.config, prettyIndent, func(payload []byte) {
+			w.Write(append(payload, '\n', '\n'))
+		})
+		return
+	}
+	marshalPooled(s.formatted(entry), &s.config, func(payload []byte) {
+		w.Write(append(payload, '\n'))
+	})
+}
+
+// formatText renders an entry as a single human-readable line.
+func formatText(entry LogEntry, color bool) string {
+	var b strings.Builder
+
+	if ts, err := time.Parse(time.RFC3339Nano, entry.Timestamp); err == nil {
+		b.WriteString(ts.Format("15:04:05"))
+	} else {
+		b.WriteString(entry.Timestamp)
+	}
+	b.WriteByte(' ')
+
+	level := fmt.Sprintf("%-5s", entry.Level)
+	if code, ok := levelColors[entry.Level]; ok && color {
+		level = code + level + ansiReset
+	}
+	b.WriteString(level)
+
+	args := entry.Args
+	if len(args) == 0 && (entry.Message != "" || len(entry.Data) > 0 || len(entry.Errors) > 0) {
+		// Entries classified by Config.ClassifyArgs without flat args.
+		args = append(append([]interface{}{entry.Message}, entry.Data...), entry.Errors...)
+	}
+	if len(args) > 0 {
+		if msg, ok := args[0].(string); ok {
+			b.WriteByte(' ')
+			b.WriteString(msg)
+			args = args[1:]
+		}
+	}
+
+	for _, arg := range args {
+		b.WriteByte(' ')
+		if keys, fields, ok := objectFields(arg); ok {
+			writeFields(&b, keys, fields)
+		} else {
+			b.WriteString(formatTextValue(arg))
+		}
+	}
+
+	b.WriteByte('\n')
+	return b.String()
+}
+
+// writeFields renders a map as space-separated key=value pairs in key order.
+func writeFields(b *strings.Builder, keys []string, fields map[string]interface{}) {
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(formatTextValue(fields[k]))
+	}
+}
+
+// formatTextValue renders plain strings as-is, quoting them only when they
+// contain whitespace or quotes, and everything else as compact JSON.
+func formatTextValue(v interface{}) string {
+	if str, ok := v.(string); ok {
+		if str == "" || strings.ContainsAny(str, " \t\n\"=") {
+			return fmt.Sprintf("%q", str)
+		}
+		return str
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
@@ -0,0 +1,83 @@
+package slogx
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// fakeTerminal is a buffer that reports itself as a TTY.
+type fakeTerminal struct {
+	bytes.Buffer
+}
+
+func (*fakeTerminal) IsTerminal() bool { return true }
+
+func TestFormatText(t *testing.T) {
+	entry := LogEntry{
+		Timestamp: "2024-03-01T09:05:07.123Z",
+		Level:     INFO,
+		Args: []interface{}{
+			"request completed",
+			map[string]interface{}{"status": 200, "path": "/api/data", "user": "Alice Smith"},
+		},
+	}
+
+	line := formatText(entry, false)
+	expected := `09:05:07 INFO  request completed path=/api/data status=200 user="Alice Smith"` + "\n"
+	if line != expected {
+		t.Errorf("expected %q, got %q", expected, line)
+	}
+}
+
+func TestConsole_TextColorOnlyForTerminal(t *testing.T) {
+	var plain bytes.Buffer
+	initCapture(t, Config{Console: &plain, ConsoleFormat: ConsoleText})
+	Warn("disk almost full", map[string]interface{}{"pct": 91})
+
+	if strings.Contains(plain.String(), "\x1b[") {
+		t.Errorf("expected no color codes for a plain writer, got %q", plain.String())
+	}
+	if !strings.Contains(plain.String(), "WARN  disk almost full pct=91") {
+		t.Errorf("unexpected text output %q", plain.String())
+	}
+
+	tty := &fakeTerminal{}
+	initCapture(t, Config{Console: tty, ConsoleFormat: ConsoleText})
+	Warn("disk almost full")
+
+	if !strings.Contains(tty.String(), levelColors[WARN]+"WARN "+ansiReset) {
+		t.Errorf("expected colored level for a terminal, got %q", tty.String())
+	}
+}
+
+func TestConsole_JSONDefault(t *testing.T) {
+	var out bytes.Buffer
+	initCapture(t, Config{Console: &out})
+	Info("hello")
+
+	var entry LogEntry
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("expected a JSON line, got %q: %v", out.String(), err)
+	}
+	if entry.Args[0] != "hello" {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+}
+
+func TestConsole_PrettyJSON(t *testing.T) {
+	var out bytes.Buffer
+	initCapture(t, Config{Console: &out, PrettyJSON: true})
+	Info("hello", map[string]interface{}{"n": 1})
+	Info("again")
+
+	chunks := strings.Split(strings.TrimSuffix(out.String(), "\n\n"), "\n\n")
+	if len(chunks) != 2 || !strings.Contains(chunks[0], "\n  \"args\"") {
+		t.Fatalf("expected two indented entries separated by a blank line, got %q", out.String())
+	}
+	var entry LogEntry
+	if err := json.Unmarshal([]byte(chunks[0]), &entry); err != nil || entry.Args[0] != "hello" {
+		t.Errorf("expected the first entry to parse back, got %+v: %v", entry, err)
+	}
+}
@@ -0,0 +1,125 @@
+package slogx
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// MarshalDeterministic encodes entry as JSON that is byte-identical for
+// entries with the same content, for golden tests and diffing sinks.
+// encoding/json already sorts map keys, but which occurrence of a DedupRefs
+// value carries the definition, and so its id, follows map iteration order.
+// Here ids are renumbered in output order and each shared value is defined
+// where it first appears in that order. entry itself is left unchanged.
+func MarshalDeterministic(entry LogEntry) ([]byte, error) {
+	c := &refCanonicalizer{
+		defs: make(map[string]interface{}),
+		ids:  make(map[string]string),
+	}
+	// Definitions may sit anywhere, including after their first reference.
+	for _, values := range [][]interface{}{entry.Args, entry.Data, entry.Errors} {
+		c.collect(values)
+	}
+	entry.Args = c.rewriteSlice(entry.Args)
+	entry.Data = c.rewriteSlice(entry.Data)
+	entry.Errors = c.rewriteSlice(entry.Errors)
+	return json.Marshal(entry)
+}
+
+// refCanonicalizer renumbers `$id`/`$ref` markers for MarshalDeterministic.
+// Objects are either plain maps or, for numeric keys, *orderedObject.
+type refCanonicalizer struct {
+	defs   map[string]interface{} // original id -> definition
+	ids    map[string]string      // original id -> new id
+	nextID int
+}
+
+func (c *refCanonicalizer) collect(v interface{}) {
+	if _, fields, ok := objectFields(v); ok {
+		if id, ok := fields["$id"].(string); ok {
+			c.defs[id] = v
+		}
+		for _, child := range fields {
+			c.collect(child)
+		}
+		return
+	}
+	if values, ok := v.([]interface{}); ok {
+		for _, child := range values {
+			c.collect(child)
+		}
+	}
+}
+
+func (c *refCanonicalizer) rewrite(v interface{}) interface{} {
+	if _, fields, ok := objectFields(v); ok {
+		id, isRef := fields["$ref"].(string)
+		if isRef && len(fields) == 1 {
+			if newID, ok := c.ids[id]; ok {
+				return map[string]interface{}{"$ref": newID}
+			}
+			if def, ok := c.defs[id]; ok {
+				return c.define(id, def)
+			}
+			return v
+		}
+		if id, ok := fields["$id"].(string); ok {
+			if newID, ok := c.ids[id]; ok {
+				return map[string]interface{}{"$ref": newID}
+			}
+			return c.define(id, v)
+		}
+		return c.rewriteObject(v, false)
+	}
+	if values, ok := v.([]interface{}); ok {
+		return c.rewriteSlice(values)
+	}
+	return v
+}
+
+// define emits the definition of a shared value at its first occurrence in
+// output order, under the next id.
+func (c *refCanonicalizer) define(id string, def interface{}) interface{} {
+	c.nextID++
+	newID := strconv.Itoa(c.nextID)
+	c.ids[id] = newID
+	result := c.rewriteObject(def, true)
+	if o, ok := result.(*orderedObject); ok {
+		o.keys = append([]string{"$id"}, o.keys...)
+		o.values["$id"] = newID
+		return o
+	}
+	result.(map[string]interface{})["$id"] = newID
+	return result
+}
+
+// rewriteObject copies an object, visiting keys in the order they will be
+// encoded so ids are handed out in output order. A definition's old `$id`
+// is skipped.
+func (c *refCanonicalizer) rewriteObject(obj interface{}, def bool) interface{} {
+	keys, fields, _ := objectFields(obj)
+	result := make(map[string]interface{}, len(fields))
+	written := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if def && k == "$id" {
+			continue
+		}
+		result[k] = c.rewrite(fields[k])
+		written = append(written, k)
+	}
+	if _, ok := obj.(*orderedObject); ok {
+		return &orderedObject{keys: written, values: result}
+	}
+	return result
+}
+
+func (c *refCanonicalizer) rewriteSlice(values []interface{}) []interface{} {
+	if values == nil {
+		return nil
+	}
+	result := make([]interface{}, len(values))
+	for i, v := range values {
+		result[i] = c.rewrite(v)
+	}
+	return result
+}
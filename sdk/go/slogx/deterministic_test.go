@@ -0,0 +1,93 @@
+package slogx
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func dedupEntry(v interface{}) LogEntry {
+	ser := newSerializer(&Config{DedupRefs: true})
+	arg := ser.serialize(v)
+	ser.finish()
+	return LogEntry{ID: "1", Level: INFO, Args: []interface{}{"state", arg}, Metadata: map[string]interface{}{"file": "x.go"}}
+}
+
+func TestMarshalDeterministic(t *testing.T) {
+	session := &mixedStruct{Public: "session", private: "token", Count: 1}
+	root := &circularStruct{Name: "root"}
+	root.Self = root
+	value := map[string]interface{}{"root": root}
+	for _, name := range []string{"alice", "bob", "carol", "dave", "erin", "frank", "grace", "heidi"} {
+		value[name] = &sharedSessionUser{Name: name, Session: session}
+	}
+
+	first, err := MarshalDeterministic(dedupEntry(value))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		again, err := MarshalDeterministic(dedupEntry(value))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(first, again) {
+			t.Fatalf("expected identical output, got\n%s\n%s", first, again)
+		}
+	}
+
+	// The session is defined under the first key in output order and
+	// referenced everywhere else.
+	var decoded LogEntry
+	if err := json.Unmarshal(first, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	users := decoded.Args[1].(map[string]interface{})
+	def := users["alice"].(map[string]interface{})["Session"].(map[string]interface{})
+	if def["$id"] != "1" || def["Public"] != "session" {
+		t.Errorf("expected the first occurrence to define the session as 1, got %v", def)
+	}
+	if ref := users["heidi"].(map[string]interface{})["Session"]; !strings.Contains(string(mustJSON(t, ref)), `"$ref":"1"`) {
+		t.Errorf("expected later occurrences to reference 1, got %v", ref)
+	}
+	self := users["root"].(map[string]interface{})
+	if self["Self"].(map[string]interface{})["$ref"] != self["$id"] {
+		t.Errorf("expected the cycle to still reference its definition, got %v", self)
+	}
+}
+
+func TestMarshalDeterministic_LeavesEntryUnchanged(t *testing.T) {
+	shared := &mixedStruct{Public: "shared"}
+	entry := dedupEntry([]interface{}{shared, shared})
+	before := mustJSON(t, entry)
+
+	if _, err := MarshalDeterministic(entry); err != nil {
+		t.Fatal(err)
+	}
+	if after := mustJSON(t, entry); !bytes.Equal(before, after) {
+		t.Errorf("expected the entry left as is, got\n%s\n%s", before, after)
+	}
+}
+
+func mustJSON(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestMarshalDeterministic_NumericKeys(t *testing.T) {
+	shared := &map[int]string{3: "c", 1: "a"}
+	value := map[int]interface{}{10: shared, 2: shared, 1: "x"}
+
+	out, err := MarshalDeterministic(dedupEntry(value))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"1":"x","2":{"$id":"1","1":"a","3":"c"},"10":{"$ref":"1"}}`; !strings.Contains(string(out), want) {
+		t.Errorf("expected numeric keys in order with the first occurrence defined, want %s in\n%s", want, out)
+	}
+}
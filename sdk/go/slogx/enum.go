@@ -0,0 +1,99 @@
+package slogx
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// EnumStyle is how values of a registered enum type are logged.
+type EnumStyle int
+
+const (
+	// EnumName logs the label, or the number when the value has none.
+	EnumName EnumStyle = iota
+	// EnumNumber logs the number only, keeping the type registered without
+	// changing its output.
+	EnumNumber
+	// EnumBoth logs {"value": 2, "name": "RUNNING"}; name is left out when
+	// the value has no label.
+	EnumBoth
+)
+
+type enumInfo struct {
+	labels map[int64]string
+	style  EnumStyle
+}
+
+var (
+	enumsMu sync.RWMutex
+	enums   = make(map[reflect.Type]enumInfo)
+)
+
+// RegisterEnum makes values of the integer type t log as their label from
+// names, e.g. RegisterEnum(reflect.TypeOf(State(0)), map[int64]string{2:
+// "RUNNING"}). Values missing from names log as plain numbers. Registering
+// a type again replaces its labels. It panics if t is not an integer type.
+func RegisterEnum(t reflect.Type, names map[int64]string) {
+	RegisterEnumStyle(t, names, EnumName)
+}
+
+// RegisterEnumStyle is RegisterEnum with a choice of how the values are
+// logged: as the label, the number, or both.
+func RegisterEnumStyle(t reflect.Type, names map[int64]string, style EnumStyle) {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	default:
+		panic(fmt.Sprintf("slogx: RegisterEnum requires an integer type, got %s", t))
+	}
+
+	labels := make(map[int64]string, len(names))
+	for v, name := range names {
+		labels[v] = name
+	}
+
+	enumsMu.Lock()
+	defer enumsMu.Unlock()
+	enums[t] = enumInfo{labels: labels, style: style}
+}
+
+// serializeEnum logs a value of a registered enum type in the type's
+// style. Values of other types are left to the generic serialization.
+func serializeEnum(val reflect.Value) (interface{}, bool) {
+	var n int64
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = val.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n = int64(val.Uint())
+	default:
+		return nil, false
+	}
+
+	enumsMu.RLock()
+	info, registered := enums[val.Type()]
+	enumsMu.RUnlock()
+	if !registered {
+		return nil, false
+	}
+
+	name, labeled := info.labels[n]
+	switch {
+	case info.style == EnumBoth:
+		var value interface{}
+		if val.CanInterface() {
+			value = val.Interface()
+		} else {
+			value = basicValue(val)
+		}
+		result := map[string]interface{}{"value": value}
+		if labeled {
+			result["name"] = name
+		}
+		return result, true
+	case info.style == EnumName && labeled:
+		return name, true
+	}
+	return nil, false
+}
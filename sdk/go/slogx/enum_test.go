@@ -0,0 +1,84 @@
+package slogx
+
+import (
+	"reflect"
+	"testing"
+)
+
+type jobState int
+
+const (
+	jobPending jobState = iota
+	jobRunning
+	jobDone
+)
+
+type jobPriority uint8
+
+func TestRegisterEnum(t *testing.T) {
+	RegisterEnum(reflect.TypeOf(jobState(0)), map[int64]string{
+		int64(jobPending): "PENDING",
+		int64(jobRunning): "RUNNING",
+	})
+	RegisterEnum(reflect.TypeOf(jobPriority(0)), map[int64]string{1: "HIGH"})
+
+	job := struct {
+		State    jobState
+		Next     jobState
+		Priority jobPriority
+		Retries  int
+	}{State: jobRunning, Next: jobDone, Priority: 1, Retries: 1}
+
+	result := Serialize(job).(map[string]interface{})
+	if result["State"] != "RUNNING" || result["Priority"] != "HIGH" {
+		t.Errorf("expected registered labels, got %v", result)
+	}
+	if result["Next"] != jobDone {
+		t.Errorf("expected unmapped value to stay numeric, got %v", result["Next"])
+	}
+	if result["Retries"] != 1 {
+		t.Errorf("expected unregistered int type to stay numeric, got %v", result["Retries"])
+	}
+}
+
+func TestRegisterEnum_RejectsNonIntegerTypes(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for a string type")
+		}
+	}()
+	RegisterEnum(reflect.TypeOf(""), map[int64]string{0: "zero"})
+}
+
+type syncPhase int
+
+type buildStep int
+
+type deployStage int
+
+func TestRegisterEnumStyle(t *testing.T) {
+	names := map[int64]string{1: "FETCH", 2: "APPLY"}
+	RegisterEnumStyle(reflect.TypeOf(syncPhase(0)), names, EnumName)
+	RegisterEnumStyle(reflect.TypeOf(buildStep(0)), names, EnumNumber)
+	RegisterEnumStyle(reflect.TypeOf(deployStage(0)), names, EnumBoth)
+
+	run := struct {
+		Phase    syncPhase
+		Step     buildStep
+		Stage    deployStage
+		Unknown  deployStage
+		previous deployStage
+	}{Phase: 2, Step: 2, Stage: 2, Unknown: 7, previous: 1}
+
+	result := Serialize(run).(map[string]interface{})
+	expected := map[string]interface{}{
+		"Phase":    "APPLY",
+		"Step":     buildStep(2),
+		"Stage":    map[string]interface{}{"value": deployStage(2), "name": "APPLY"},
+		"Unknown":  map[string]interface{}{"value": deployStage(7)},
+		"previous": map[string]interface{}{"value": deployStage(1), "name": "FETCH"},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected each type in its registered style, got %#v", result)
+	}
+}
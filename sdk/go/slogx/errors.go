@@ -0,0 +1,86 @@
+package slogx
+
+import (
+	"errors"
+	"fmt"
+)
+
+// defaultMaxStackLen caps a `%+v` error rendering when MaxStringLen is unset.
+const defaultMaxStackLen = 32 * 1024
+
+// serializeError converts an error arg into the block the viewer renders and
+// returns the stack to attach to the entry. Errors implementing fmt.Formatter
+// (e.g. github.com/pkg/errors) carry their own stack via `%+v`, which is more
+// useful than the synthetic stack of the logging call.
+func (s *serializer) serializeError(err error, callerStack string) (map[string]interface{}, string) {
+	message := s.sanitizeString(err.Error())
+
+	stack, ok := s.formatterStack(err)
+	if !ok {
+		stack = fmt.Sprintf("%s\n%s", message, callerStack)
+	}
+
+	block := map[string]interface{}{
+		"name":    "Error",
+		"message": message,
+		"stack":   stack,
+	}
+	s.addCauses(block, err, 0)
+	return block, stack
+}
+
+// maxErrorDepth bounds how far nested causes are followed.
+const maxErrorDepth = 32
+
+// addCauses attaches the errors wrapped by err to its block: a joined error
+// (Unwrap() []error, e.g. errors.Join) lists each under "errors", and a
+// single wrapped error is nested as "cause".
+func (s *serializer) addCauses(block map[string]interface{}, err error, depth int) {
+	if depth == maxErrorDepth {
+		return
+	}
+
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var children []interface{}
+		for _, child := range joined.Unwrap() {
+			if child != nil {
+				children = append(children, s.causeBlock(child, depth+1))
+			}
+		}
+		if children != nil {
+			block["errors"] = children
+		}
+		return
+	}
+
+	if cause := errors.Unwrap(err); cause != nil {
+		block["cause"] = s.causeBlock(cause, depth+1)
+	}
+}
+
+// causeBlock renders a wrapped error. Only errors that carry their own
+// stack via `%+v` get one; the logging call's stack belongs to the top.
+func (s *serializer) causeBlock(err error, depth int) map[string]interface{} {
+	block := map[string]interface{}{
+		"name":    "Error",
+		"message": s.sanitizeString(err.Error()),
+	}
+	if stack, ok := s.formatterStack(err); ok {
+		block["stack"] = stack
+	}
+	s.addCauses(block, err, depth)
+	return block
+}
+
+// formatterStack renders the stack an error carries itself, reporting false
+// when it doesn't implement fmt.Formatter.
+func (s *serializer) formatterStack(err error) (string, bool) {
+	if _, ok := err.(fmt.Formatter); !ok {
+		return "", false
+	}
+	limit := s.config.MaxStringLen
+	if limit <= 0 {
+		limit = defaultMaxStackLen
+	}
+	return truncateString(s.sanitizeString(fmt.Sprintf("%+v", err)), limit), true
+}
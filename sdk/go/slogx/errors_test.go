@@ -0,0 +1,136 @@
+package slogx
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// stackError mimics github.com/pkg/errors: `%+v` prints the message followed
+// by the stack captured where the error was created.
+type stackError struct {
+	msg   string
+	stack string
+}
+
+func (e *stackError) Error() string { return e.msg }
+
+func (e *stackError) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('+') {
+		io.WriteString(f, e.msg+"\n"+e.stack)
+		return
+	}
+	io.WriteString(f, e.msg)
+}
+
+func TestSerializeError_FormatterStack(t *testing.T) {
+	err := &stackError{msg: "db timeout", stack: "main.query\n\t/app/db.go:42\nmain.main\n\t/app/main.go:10"}
+
+	ser := newSerializer(&Config{})
+	block, stack := ser.serializeError(err, "at caller (x.go:1)\n")
+
+	if block["message"] != "db timeout" {
+		t.Errorf("expected message, got %v", block["message"])
+	}
+	if !strings.Contains(stack, "/app/db.go:42") {
+		t.Errorf("expected %%+v stack to be captured, got %q", stack)
+	}
+	if strings.Contains(stack, "x.go:1") {
+		t.Errorf("expected formatter stack to replace the caller stack, got %q", stack)
+	}
+	if block["stack"] != stack {
+		t.Errorf("expected block stack to match entry stack")
+	}
+}
+
+func TestSerializeError_FormatterStackTruncated(t *testing.T) {
+	err := &stackError{msg: "huge", stack: strings.Repeat("frame\n", 1000)}
+
+	ser := newSerializer(&Config{MaxStringLen: 100})
+	_, stack := ser.serializeError(err, "")
+
+	if !strings.HasPrefix(stack, "huge\nframe") || !strings.Contains(stack, "…[truncated") {
+		t.Errorf("expected truncated stack, got %q", stack)
+	}
+	if len(stack) > 150 {
+		t.Errorf("expected stack near the limit, got %d bytes", len(stack))
+	}
+}
+
+func TestSerializeError_PlainError(t *testing.T) {
+	ser := newSerializer(&Config{})
+	_, stack := ser.serializeError(errors.New("plain"), "at caller (x.go:1)\n")
+
+	if stack != "plain\nat caller (x.go:1)\n" {
+		t.Errorf("expected message plus caller stack, got %q", stack)
+	}
+}
+
+func TestStackForErrorsOnly(t *testing.T) {
+	read := initCapture(t, Config{StackForErrorsOnly: true})
+
+	Info("routine")
+	Error("failed")
+	Warn("retrying", errors.New("timeout"))
+
+	entries := read()
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	if entries[0].Stacktrace != "" {
+		t.Errorf("expected no stacktrace on INFO, got %q", entries[0].Stacktrace)
+	}
+	if entries[0].Metadata["file"] != "errors_test.go" {
+		t.Errorf("expected caller metadata to remain, got %v", entries[0].Metadata)
+	}
+	if !strings.Contains(entries[1].Stacktrace, "TestStackForErrorsOnly") {
+		t.Errorf("expected stacktrace on ERROR, got %q", entries[1].Stacktrace)
+	}
+	if !strings.HasPrefix(entries[2].Stacktrace, "timeout\n") {
+		t.Errorf("expected an explicit error to keep its stacktrace, got %q", entries[2].Stacktrace)
+	}
+}
+
+func TestSerializeError_Joined(t *testing.T) {
+	e1 := errors.New("disk full")
+	e2 := &stackError{msg: "upload failed", stack: "main.upload\n\t/app/upload.go:7"}
+
+	ser := newSerializer(&Config{})
+	block, _ := ser.serializeError(errors.Join(e1, e2), "")
+
+	children, ok := block["errors"].([]interface{})
+	if !ok || len(children) != 2 {
+		t.Fatalf("expected two joined error blocks, got %v", block["errors"])
+	}
+	first := children[0].(map[string]interface{})
+	second := children[1].(map[string]interface{})
+	if first["message"] != "disk full" || first["stack"] != nil {
+		t.Errorf("expected plain error without a stack, got %v", first)
+	}
+	if second["message"] != "upload failed" || !strings.Contains(second["stack"].(string), "/app/upload.go:7") {
+		t.Errorf("expected formatter error with its own stack, got %v", second)
+	}
+}
+
+func TestSerializeError_JoinedWithWrappedCause(t *testing.T) {
+	root := errors.New("connection refused")
+	wrapped := fmt.Errorf("query users: %w", root)
+
+	ser := newSerializer(&Config{})
+	block, _ := ser.serializeError(fmt.Errorf("request failed: %w", errors.Join(wrapped, errors.New("cache miss"))), "")
+
+	joined := block["cause"].(map[string]interface{})
+	children := joined["errors"].([]interface{})
+	if len(children) != 2 {
+		t.Fatalf("expected joined errors under the cause, got %v", joined)
+	}
+	query := children[0].(map[string]interface{})
+	if query["message"] != "query users: connection refused" {
+		t.Errorf("unexpected first joined error %v", query)
+	}
+	if cause := query["cause"].(map[string]interface{}); cause["message"] != "connection refused" {
+		t.Errorf("expected the wrapped cause nested, got %v", cause)
+	}
+}
@@ -0,0 +1,82 @@
+package slogx
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+type flagName struct {
+	bits uint64
+	name string
+}
+
+var (
+	flagsMu sync.RWMutex
+	flags   = make(map[reflect.Type][]flagName)
+)
+
+// RegisterFlags makes values of the integer bitmask type t log as the list
+// of names whose bits are set, e.g. 0b101 as ["READ", "EXECUTE"]. Bits with
+// no name are kept as one number at the end of the list, and a value with
+// no bits set logs as []. Registering a type again replaces its names. It
+// panics if t is not an integer type.
+func RegisterFlags(t reflect.Type, names map[uint64]string) {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	default:
+		panic(fmt.Sprintf("slogx: RegisterFlags requires an integer type, got %s", t))
+	}
+
+	sorted := make([]flagName, 0, len(names))
+	for bits, name := range names {
+		if bits != 0 {
+			sorted = append(sorted, flagName{bits: bits, name: name})
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].bits < sorted[j].bits })
+
+	flagsMu.Lock()
+	defer flagsMu.Unlock()
+	flags[t] = sorted
+}
+
+// serializeFlags decomposes a value of a registered bitmask type into its
+// flag names. Values of other types are left to the generic serialization.
+func serializeFlags(val reflect.Value) (interface{}, bool) {
+	var n uint64
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = uint64(val.Int())
+		// Keep only the type's own bits so negative values don't sign-extend.
+		if bits := val.Type().Bits(); bits < 64 {
+			n &= 1<<bits - 1
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n = val.Uint()
+	default:
+		return nil, false
+	}
+
+	flagsMu.RLock()
+	names, registered := flags[val.Type()]
+	flagsMu.RUnlock()
+	if !registered {
+		return nil, false
+	}
+
+	result := []interface{}{}
+	rest := n
+	for _, f := range names {
+		if n&f.bits == f.bits {
+			result = append(result, f.name)
+			rest &^= f.bits
+		}
+	}
+	if rest != 0 {
+		result = append(result, rest)
+	}
+	return result, true
+}
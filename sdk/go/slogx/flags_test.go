@@ -0,0 +1,54 @@
+package slogx
+
+import (
+	"reflect"
+	"testing"
+)
+
+type filePerm uint8
+
+const (
+	permRead filePerm = 1 << iota
+	permWrite
+	permExecute
+)
+
+type featureSet int8
+
+func TestRegisterFlags(t *testing.T) {
+	RegisterFlags(reflect.TypeOf(filePerm(0)), map[uint64]string{
+		uint64(permRead):    "READ",
+		uint64(permWrite):   "WRITE",
+		uint64(permExecute): "EXECUTE",
+	})
+	RegisterFlags(reflect.TypeOf(featureSet(0)), map[uint64]string{1: "BETA"})
+
+	result := Serialize(map[string]interface{}{
+		"multiple": permRead | permExecute,
+		"none":     filePerm(0),
+		"unknown":  permWrite | 0b11000,
+		"signed":   featureSet(-1),
+	}).(map[string]interface{})
+
+	if got := result["multiple"]; !reflect.DeepEqual(got, []interface{}{"READ", "EXECUTE"}) {
+		t.Errorf("expected the set flag names in bit order, got %v", got)
+	}
+	if got := result["none"]; !reflect.DeepEqual(got, []interface{}{}) {
+		t.Errorf("expected an empty list with no flags set, got %v", got)
+	}
+	if got := result["unknown"]; !reflect.DeepEqual(got, []interface{}{"WRITE", uint64(0b11000)}) {
+		t.Errorf("expected unnamed bits kept as a number, got %v", got)
+	}
+	if got := result["signed"]; !reflect.DeepEqual(got, []interface{}{"BETA", uint64(0xfe)}) {
+		t.Errorf("expected a negative value limited to the type's bits, got %v", got)
+	}
+}
+
+func TestRegisterFlags_RejectsNonIntegerTypes(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for a float type")
+		}
+	}()
+	RegisterFlags(reflect.TypeOf(0.0), map[uint64]string{1: "ONE"})
+}
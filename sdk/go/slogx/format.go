@@ -0,0 +1,138 @@
+package slogx
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FormatFunc renders a struct field tagged `slogx:"format=name"`. It
+// returns false when it doesn't apply to v, which is then logged as usual.
+type FormatFunc func(v interface{}) (out interface{}, ok bool)
+
+var (
+	formatsMu sync.RWMutex
+	formats   = map[string]FormatFunc{
+		"hex":     formatHex,
+		"base64":  formatBase64,
+		"unix":    formatUnix,
+		"rfc3339": formatRFC3339,
+	}
+)
+
+// RegisterFormat adds a named field format for the `slogx:"format=name"`
+// struct tag, replacing any format of that name, including the built-in
+// hex, base64, unix and rfc3339.
+func RegisterFormat(name string, fn FormatFunc) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	formats[name] = fn
+}
+
+// fieldFormat returns the format named in a field's slogx tag, if any. The
+// tag holds comma-separated options, e.g. `slogx:"format=hex"`.
+func fieldFormat(field reflect.StructField) string {
+	for _, opt := range strings.Split(field.Tag.Get("slogx"), ",") {
+		if name, ok := strings.CutPrefix(strings.TrimSpace(opt), "format="); ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// formatField applies a field's format tag. It reports false when the
+// field has no known format or the format doesn't apply, so the caller
+// serializes the field as usual. Redacted paths stay redacted.
+func (s *serializer) formatField(field reflect.StructField, val reflect.Value) (interface{}, bool) {
+	name := fieldFormat(field)
+	if name == "" {
+		return nil, false
+	}
+	formatsMu.RLock()
+	fn := formats[name]
+	formatsMu.RUnlock()
+	if fn == nil {
+		return nil, false
+	}
+
+	// Path rules win over the format, so a secret can't leak formatted.
+	if s.tracksPaths() {
+		s.path = append(s.path, field.Name)
+		redacted, hashed := s.pathRedacted(), s.pathMatches(s.hashPaths)
+		s.path = s.path[:len(s.path)-1]
+		if redacted {
+			return "[redacted]", true
+		}
+		if hashed {
+			return s.hashValue(val), true
+		}
+	}
+
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return nil, false
+		}
+		val = val.Elem()
+	}
+	if !val.CanInterface() {
+		return nil, false
+	}
+	return fn(val.Interface())
+}
+
+// formatBytes returns the bytes of a string, byte slice or byte array.
+func formatBytes(v interface{}) ([]byte, bool) {
+	val := reflect.ValueOf(v)
+	switch {
+	case val.Kind() == reflect.String:
+		return []byte(val.String()), true
+	case val.Kind() == reflect.Slice && val.Type().Elem().Kind() == reflect.Uint8:
+		return val.Bytes(), true
+	case val.Kind() == reflect.Array && val.Type().Elem().Kind() == reflect.Uint8:
+		b := make([]byte, val.Len())
+		reflect.Copy(reflect.ValueOf(b), val)
+		return b, true
+	}
+	return nil, false
+}
+
+// formatHex renders bytes and strings as hex digits and integers as 0x….
+func formatHex(v interface{}) (interface{}, bool) {
+	if b, ok := formatBytes(v); ok {
+		return hex.EncodeToString(b), true
+	}
+	val := reflect.ValueOf(v)
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fmt.Sprintf("%#x", val.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return fmt.Sprintf("%#x", val.Uint()), true
+	}
+	return nil, false
+}
+
+func formatBase64(v interface{}) (interface{}, bool) {
+	if b, ok := formatBytes(v); ok {
+		return base64.StdEncoding.EncodeToString(b), true
+	}
+	return nil, false
+}
+
+// formatUnix renders a time as Unix seconds.
+func formatUnix(v interface{}) (interface{}, bool) {
+	if t, ok := v.(time.Time); ok {
+		return t.Unix(), true
+	}
+	return nil, false
+}
+
+func formatRFC3339(v interface{}) (interface{}, bool) {
+	if t, ok := v.(time.Time); ok {
+		return t.Format(time.RFC3339Nano), true
+	}
+	return nil, false
+}
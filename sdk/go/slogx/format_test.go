@@ -0,0 +1,104 @@
+package slogx
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type uploadRecord struct {
+	Checksum  [4]byte    `slogx:"format=hex"`
+	Flags     uint16     `slogx:"format=hex"`
+	Thumbnail []byte     `slogx:"format=base64"`
+	Created   time.Time  `slogx:"format=unix"`
+	Modified  *time.Time `slogx:"format=rfc3339"`
+	Expires   *time.Time `slogx:"format=rfc3339"`
+	Owner     string     `slogx:"format=shouty"`
+	Size      int        `slogx:"format=nonexistent"`
+	secret    []byte     `slogx:"format=hex"`
+}
+
+func TestSerialize_FormatTags(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	modified := created.Add(90 * time.Minute)
+	record := uploadRecord{
+		Checksum:  [4]byte{0xde, 0xad, 0xbe, 0xef},
+		Flags:     0x1f,
+		Thumbnail: []byte("png"),
+		Created:   created,
+		Modified:  &modified,
+		Owner:     "ann",
+		Size:      42,
+		secret:    []byte{0x01, 0xff},
+	}
+
+	result := Serialize(record).(map[string]interface{})
+	expected := map[string]interface{}{
+		"Checksum":  "deadbeef",
+		"Flags":     "0x1f",
+		"Thumbnail": "cG5n",
+		"Created":   created.Unix(),
+		"Modified":  "2024-05-01T13:30:00Z",
+		"Expires":   nil,
+		"secret":    "01ff",
+	}
+	for name, want := range expected {
+		if !reflect.DeepEqual(result[name], want) {
+			t.Errorf("%s: expected %#v, got %#v", name, want, result[name])
+		}
+	}
+
+	// Unknown formats fall back to the default serialization.
+	if result["Owner"] != "ann" || result["Size"] != 42 {
+		t.Errorf("expected unformatted fields, got Owner=%v Size=%v", result["Owner"], result["Size"])
+	}
+}
+
+func TestRegisterFormat(t *testing.T) {
+	RegisterFormat("shouty", func(v interface{}) (interface{}, bool) {
+		s, ok := v.(string)
+		return strings.ToUpper(s), ok
+	})
+	defer func() {
+		formatsMu.Lock()
+		delete(formats, "shouty")
+		formatsMu.Unlock()
+	}()
+
+	result := Serialize(uploadRecord{Owner: "ann"}).(map[string]interface{})
+	if result["Owner"] != "ANN" {
+		t.Errorf("expected the registered format applied, got %v", result["Owner"])
+	}
+
+	ser := newSerializer(&Config{RedactPaths: []string{"Owner"}})
+	result = ser.serialize(uploadRecord{Owner: "ann"}).(map[string]interface{})
+	if result["Owner"] != "[redacted]" {
+		t.Errorf("expected redaction to win over formats, got %v", result["Owner"])
+	}
+}
+
+type signedToken struct {
+	Token  []byte `slogx:"format=hex"`
+	Digest []byte `slogx:"format=hex,hash"`
+}
+
+func TestSerialize_FormatTagsHashed(t *testing.T) {
+	token := signedToken{Token: []byte("secret"), Digest: []byte("secret")}
+
+	ser := newSerializer(&Config{HashPaths: []string{"Token"}})
+	result := ser.serialize(token).(map[string]interface{})
+	for _, field := range []string{"Token", "Digest"} {
+		if s, _ := result[field].(string); !strings.HasPrefix(s, "[hash:") {
+			t.Errorf("expected %s hashed rather than formatted, got %v", field, result[field])
+		}
+	}
+	if result["Token"] != result["Digest"] {
+		t.Errorf("expected HashPaths to hash like the tag, got %v and %v", result["Token"], result["Digest"])
+	}
+
+	ser = newSerializer(&Config{RedactPaths: []string{"Token"}, HashPaths: []string{"Token"}})
+	if result := ser.serialize(token).(map[string]interface{}); result["Token"] != "[redacted]" {
+		t.Errorf("expected redaction to win over hashing, got %v", result["Token"])
+	}
+}
@@ -0,0 +1,61 @@
+package slogx
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// hashKey salts hashed values. It is random per process, so equal values
+// hash alike within a run but can't be matched across runs, or guessed by
+// hashing candidates offline.
+var hashKey = func() []byte {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return key
+}()
+
+// fieldHashed reports whether a field is tagged `slogx:"hash"`.
+func fieldHashed(field reflect.StructField) bool {
+	for _, opt := range strings.Split(field.Tag.Get("slogx"), ",") {
+		if strings.TrimSpace(opt) == "hash" {
+			return true
+		}
+	}
+	return false
+}
+
+// hashField hashes a field tagged `slogx:"hash"`. Redacted paths stay
+// redacted.
+func (s *serializer) hashField(field reflect.StructField, val reflect.Value) interface{} {
+	if s.redactPaths != nil {
+		s.path = append(s.path, field.Name)
+		redacted := s.pathRedacted()
+		s.path = s.path[:len(s.path)-1]
+		if redacted {
+			return "[redacted]"
+		}
+	}
+	return s.hashValue(val)
+}
+
+// hashValue replaces a value with a short salted hash of its serialized
+// form, e.g. "[hash:3f9a1c2b7e4d]", so occurrences of a secret can be
+// correlated without logging it. Nil values stay null.
+func (s *serializer) hashValue(val reflect.Value) interface{} {
+	result := s.serializeValue(val)
+	if result == nil {
+		return nil
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return "[redacted]"
+	}
+	mac := hmac.New(sha256.New, hashKey)
+	mac.Write(data)
+	return "[hash:" + hex.EncodeToString(mac.Sum(nil)[:6]) + "]"
+}
@@ -0,0 +1,114 @@
+package slogx
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+var (
+	httpRequestType  = reflect.TypeOf((*http.Request)(nil))
+	httpResponseType = reflect.TypeOf((*http.Response)(nil))
+)
+
+// defaultRedactHeaders are the headers whose values are hidden when
+// Config.RedactHeaders is nil.
+var defaultRedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+func (c *Config) redactHeaders() []string {
+	if c.RedactHeaders != nil {
+		return c.RedactHeaders
+	}
+	return defaultRedactHeaders
+}
+
+// serializeHTTP summarizes requests and responses instead of reflecting
+// into them, which would log bodies, TLS state and connection internals,
+// and headers such as Authorization in the clear.
+func (s *serializer) serializeHTTP(val reflect.Value) (interface{}, bool) {
+	switch val.Type() {
+	case httpRequestType, httpRequestType.Elem(), httpResponseType, httpResponseType.Elem():
+		if !val.CanInterface() {
+			// Reflecting would expose the headers; settle for the type.
+			return fmt.Sprintf("<%s>", val.Type()), true
+		}
+	default:
+		return nil, false
+	}
+
+	switch val.Type() {
+	case httpRequestType:
+		if val.IsNil() {
+			return nil, true
+		}
+		return s.requestSummary(val.Interface().(*http.Request)), true
+	case httpRequestType.Elem():
+		r := val.Interface().(http.Request)
+		return s.requestSummary(&r), true
+	case httpResponseType:
+		if val.IsNil() {
+			return nil, true
+		}
+		return s.responseSummary(val.Interface().(*http.Response)), true
+	case httpResponseType.Elem():
+		resp := val.Interface().(http.Response)
+		return s.responseSummary(&resp), true
+	}
+	return nil, false
+}
+
+// requestSummary logs a request as method, URL (without a password), host,
+// remote address and headers.
+func (s *serializer) requestSummary(r *http.Request) map[string]interface{} {
+	result := map[string]interface{}{
+		"method":  r.Method,
+		"headers": s.headerSummary(r.Header),
+	}
+	if r.URL != nil {
+		result["url"] = s.sanitizeString(r.URL.Redacted())
+	}
+	if r.Host != "" {
+		result["host"] = s.sanitizeString(r.Host)
+	}
+	if r.RemoteAddr != "" {
+		result["remoteAddr"] = r.RemoteAddr
+	}
+	return result
+}
+
+// responseSummary logs a response as its status code and text, headers,
+// and the method and URL of the request it answers.
+func (s *serializer) responseSummary(resp *http.Response) map[string]interface{} {
+	result := map[string]interface{}{
+		"status":     resp.StatusCode,
+		"statusText": http.StatusText(resp.StatusCode),
+		"headers":    s.headerSummary(resp.Header),
+	}
+	if r := resp.Request; r != nil && r.URL != nil {
+		result["request"] = r.Method + " " + s.sanitizeString(r.URL.Redacted())
+	}
+	return result
+}
+
+// headerSummary joins each header's values with ", " and hides the values
+// of headers listed in RedactHeaders.
+func (s *serializer) headerSummary(h http.Header) map[string]interface{} {
+	redact := s.config.redactHeaders()
+	result := make(map[string]interface{}, len(h))
+	for name, values := range h {
+		redacted := false
+		for _, r := range redact {
+			if strings.EqualFold(name, r) {
+				redacted = true
+				break
+			}
+		}
+		if redacted {
+			result[name] = "[redacted]"
+		} else {
+			result[name] = s.sanitizeString(strings.Join(values, ", "))
+		}
+	}
+	return result
+}
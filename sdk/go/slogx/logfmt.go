@@ -0,0 +1,107 @@
+package slogx
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// formatLogfmt renders an entry as a logfmt line:
+// `time=… level=INFO msg="request done" status=200 user.name=ann`. Map
+// args become key=value pairs, nested maps with dotted keys; other args are
+// keyed by position (`arg1=…`), and slices are inlined as JSON.
+func formatLogfmt(entry LogEntry) string {
+	var b strings.Builder
+	writeLogfmtPair(&b, "time", entry.Timestamp)
+	writeLogfmtPair(&b, "level", string(entry.Level))
+	if entry.Component != "" {
+		writeLogfmtPair(&b, "component", entry.Component)
+	}
+
+	args := entry.Args
+	if len(args) == 0 && (entry.Message != "" || len(entry.Data) > 0 || len(entry.Errors) > 0) {
+		// Entries classified by Config.ClassifyArgs without flat args.
+		args = append(append([]interface{}{entry.Message}, entry.Data...), entry.Errors...)
+	}
+	first := 0
+	if len(args) > 0 {
+		if msg, ok := args[0].(string); ok {
+			writeLogfmtPair(&b, "msg", msg)
+			first = 1
+		}
+	}
+
+	for i := first; i < len(args); i++ {
+		if keys, fields, ok := objectFields(args[i]); ok {
+			writeLogfmtFields(&b, "", keys, fields)
+		} else {
+			writeLogfmtPair(&b, "arg"+strconv.Itoa(i), logfmtText(args[i]))
+		}
+	}
+
+	b.WriteByte('\n')
+	return b.String()
+}
+
+// writeLogfmtFields writes a map's entries in key order, descending into
+// nested maps with dotted keys.
+func writeLogfmtFields(b *strings.Builder, prefix string, keys []string, fields map[string]interface{}) {
+	for _, k := range keys {
+		key := prefix + k
+		if nestedKeys, nested, ok := objectFields(fields[k]); ok && len(nestedKeys) > 0 {
+			writeLogfmtFields(b, key+".", nestedKeys, nested)
+			continue
+		}
+		writeLogfmtPair(b, key, logfmtText(fields[k]))
+	}
+}
+
+// logfmtText renders a field value: strings as-is, everything else as
+// compact JSON.
+func logfmtText(v interface{}) string {
+	if str, ok := v.(string); ok {
+		return str
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
+
+func writeLogfmtPair(b *strings.Builder, key, value string) {
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+	b.WriteString(logfmtKey(key))
+	b.WriteByte('=')
+	b.WriteString(logfmtValue(value))
+}
+
+// logfmtKey replaces the characters a key can't hold with underscores.
+func logfmtKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == 0x7f {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+// logfmtValue quotes a value that is empty or holds spaces, quotes, `=` or
+// control characters, escaping it the way Go string literals are.
+func logfmtValue(value string) string {
+	if value == "" {
+		return `""`
+	}
+	for _, r := range value {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == 0x7f {
+			return strconv.Quote(value)
+		}
+	}
+	return value
+}
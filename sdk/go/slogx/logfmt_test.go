@@ -0,0 +1,47 @@
+package slogx
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFormatLogfmt(t *testing.T) {
+	entry := LogEntry{
+		Timestamp: "2024-03-01T09:05:07.123Z",
+		Level:     WARN,
+		Args: []interface{}{
+			"payment declined",
+			map[string]interface{}{
+				"amount":  12.5,
+				"reason":  `card "expired"`,
+				"note":    "",
+				"user":    map[string]interface{}{"name": "Ann Lee", "id": 7},
+				"tags":    []interface{}{"eu", "retry"},
+				"bad key": "a=b",
+			},
+			42,
+		},
+	}
+
+	expected := `time=2024-03-01T09:05:07.123Z level=WARN msg="payment declined" amount=12.5 bad_key="a=b" note="" ` +
+		`reason="card \"expired\"" tags="[\"eu\",\"retry\"]" user.id=7 user.name="Ann Lee" arg2=42` + "\n"
+	if line := formatLogfmt(entry); line != expected {
+		t.Errorf("expected\n%q\ngot\n%q", expected, line)
+	}
+
+	multiline := LogEntry{Timestamp: "t", Level: INFO, Args: []interface{}{"line one\nline two", `C:\tmp`}}
+	if line := formatLogfmt(multiline); line != `time=t level=INFO msg="line one\nline two" arg1="C:\\tmp"`+"\n" {
+		t.Errorf("expected newlines and backslashes escaped, got %q", line)
+	}
+}
+
+func TestConsole_Logfmt(t *testing.T) {
+	var out bytes.Buffer
+	initCapture(t, Config{Console: &out, ConsoleFormat: ConsoleLogfmt})
+	Info("cache warmed", map[string]interface{}{"keys": 120})
+
+	if !strings.HasPrefix(out.String(), "time=") || !strings.HasSuffix(out.String(), `level=INFO msg="cache warmed" keys=120`+"\n") {
+		t.Errorf("unexpected logfmt output %q", out.String())
+	}
+}
@@ -0,0 +1,60 @@
+package slogx
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Loggable lets a type choose how it is logged: the value returned by
+// SlogxLog is serialized in its place.
+type Loggable interface {
+	SlogxLog() interface{}
+}
+
+// LoggableE is Loggable for representations that can fail. On error the
+// value is logged as "[log error: ...]". It takes precedence over Loggable
+// when a type implements both.
+type LoggableE interface {
+	SlogxLogE() (interface{}, error)
+}
+
+var (
+	loggableType  = reflect.TypeOf((*Loggable)(nil)).Elem()
+	loggableEType = reflect.TypeOf((*LoggableE)(nil)).Elem()
+)
+
+// serializeLoggable serializes the representation a Loggable or LoggableE
+// value provides. A panicking method is reported like a returned error.
+func (s *serializer) serializeLoggable(val reflect.Value) (result interface{}, ok bool) {
+	t := val.Type()
+	isE := t.Implements(loggableEType)
+	if !isE && !t.Implements(loggableType) {
+		return nil, false
+	}
+	if !val.CanInterface() || (val.Kind() == reflect.Ptr && val.IsNil()) {
+		return nil, false
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			result, ok = fmt.Sprintf("[log error: panic: %v]", r), true
+		}
+	}()
+
+	// Each representation counts as a level, so one that returns its own
+	// receiver stops at MaxDepth instead of overflowing the stack.
+	if s.depth >= s.maxDepth() {
+		return maxDepthMarker, true
+	}
+	s.depth++
+	defer func() { s.depth-- }()
+
+	if isE {
+		v, err := val.Interface().(LoggableE).SlogxLogE()
+		if err != nil {
+			return s.sanitizeString(fmt.Sprintf("[log error: %v]", err)), true
+		}
+		return s.serialize(v), true
+	}
+	return s.serialize(val.Interface().(Loggable).SlogxLog()), true
+}
@@ -0,0 +1,26 @@
+package slogx
+
+import "runtime"
+
+// MemStats logs a snapshot of the runtime's memory stats as a "memstats"
+// entry with alloc, heapInuse, numGC and goroutines, so memory use can be
+// lined up with the entries around it. It runs runtime.ReadMemStats, which
+// briefly stops the world, so call it deliberately rather than per request.
+func MemStats(level LogLevel) {
+	MemStatsSkip(1, level)
+}
+
+// MemStatsSkip is MemStats for logging wrappers; skip works as in LogSkip.
+func MemStatsSkip(skip int, level LogLevel) {
+	if !getInstance().enabled(level, nil) {
+		return
+	}
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	log(skip, level, "memstats", map[string]interface{}{
+		"alloc":      m.Alloc,
+		"heapInuse":  m.HeapInuse,
+		"numGC":      m.NumGC,
+		"goroutines": runtime.NumGoroutine(),
+	})
+}
@@ -0,0 +1,33 @@
+package slogx
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestMemStats(t *testing.T) {
+	read := initCapture(t, Config{})
+
+	runtime.GC()
+	MemStats(INFO)
+	MemStats(TRACE)
+
+	entries := read()
+	if len(entries) != 1 {
+		t.Fatalf("expected one entry with TRACE filtered, got %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.Args[0] != "memstats" || entry.Metadata["file"] != "memstats_test.go" {
+		t.Errorf("expected a memstats entry from the caller, got %v from %v", entry.Args[0], entry.Metadata["file"])
+	}
+
+	stats := entry.Args[1].(map[string]interface{})
+	for _, field := range []string{"alloc", "heapInuse", "numGC", "goroutines"} {
+		if n, ok := stats[field].(float64); !ok || n < 1 {
+			t.Errorf("expected a positive %s, got %v", field, stats[field])
+		}
+	}
+	if stats["heapInuse"].(float64) < stats["alloc"].(float64) {
+		t.Errorf("expected in-use heap spans to cover the allocated bytes, got %v", stats)
+	}
+}
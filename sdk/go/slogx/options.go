@@ -0,0 +1,78 @@
+package slogx
+
+import (
+	"context"
+	"time"
+)
+
+// Option is a special log argument that adjusts the entry being built
+// instead of being serialized into Args.
+type Option interface {
+	applyOption(o *entryOptions)
+}
+
+type entryOptions struct {
+	timestamp time.Time
+	// ack waits for queued clients to write the entry so the delivered
+	// count is exact; set by LogAck.
+	ack bool
+	// ctx, set by LogCtx, stops the broadcast once it is done.
+	ctx context.Context
+	// component, set by Component, tags the entry with its component.
+	component string
+	// chain, from a WithChain ctx, links the entry once it is emitted.
+	chain *chain
+}
+
+type timestampOption time.Time
+
+func (t timestampOption) applyOption(o *entryOptions) {
+	o.timestamp = time.Time(t)
+}
+
+type ackOption struct{}
+
+func (ackOption) applyOption(o *entryOptions) {
+	o.ack = true
+}
+
+type ctxOption struct{ ctx context.Context }
+
+func (c ctxOption) applyOption(o *entryOptions) {
+	o.ctx = c.ctx
+}
+
+type componentOption string
+
+func (c componentOption) applyOption(o *entryOptions) {
+	o.component = string(c)
+}
+
+// At overrides an entry's timestamp with the time the event actually
+// occurred, e.g. when replaying queued events. The time the entry was logged
+// is kept in metadata as `ingestedAt`. A zero time is ignored.
+func At(t time.Time) Option {
+	return timestampOption(t)
+}
+
+// Component tags an entry with the part of the application that logged it,
+// e.g. Info(slogx.Component("db"), "query done"). The name goes in the
+// entry's `component` field, which viewers can subscribe to, rather than
+// in Args. The last Component passed wins.
+func Component(name string) Option {
+	return componentOption(name)
+}
+
+// splitOptions separates Option arguments from the values to be logged.
+func splitOptions(args []interface{}) (entryOptions, []interface{}) {
+	var opts entryOptions
+	values := make([]interface{}, 0, len(args))
+	for _, arg := range args {
+		if opt, ok := arg.(Option); ok {
+			opt.applyOption(&opts)
+			continue
+		}
+		values = append(values, arg)
+	}
+	return opts, values
+}
@@ -0,0 +1,77 @@
+package slogx
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAt_OverridesTimestamp(t *testing.T) {
+	read := initCapture(t, Config{})
+
+	eventTime := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	before := time.Now().UTC()
+	Info("replayed event", At(eventTime), map[string]interface{}{"id": 7})
+
+	entries := read()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	entry := entries[0]
+
+	if entry.Timestamp != eventTime.Format(time.RFC3339Nano) {
+		t.Errorf("expected timestamp %s, got %s", eventTime.Format(time.RFC3339Nano), entry.Timestamp)
+	}
+
+	ingested, err := time.Parse(time.RFC3339Nano, entry.Metadata["ingestedAt"].(string))
+	if err != nil {
+		t.Fatalf("expected ingestedAt in metadata: %v", err)
+	}
+	if ingested.Before(before) {
+		t.Errorf("expected ingestedAt to be the logging time, got %s", ingested)
+	}
+
+	// The option itself is not logged as an argument.
+	if len(entry.Args) != 2 {
+		t.Errorf("expected 2 args, got %v", entry.Args)
+	}
+}
+
+func TestAt_ZeroTimeIgnored(t *testing.T) {
+	read := initCapture(t, Config{})
+
+	Info("no override", At(time.Time{}))
+
+	entry := read()[0]
+	ts, err := time.Parse(time.RFC3339Nano, entry.Timestamp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(ts) > time.Minute {
+		t.Errorf("expected generated timestamp, got %s", entry.Timestamp)
+	}
+	if _, ok := entry.Metadata["ingestedAt"]; ok {
+		t.Error("expected no ingestedAt without an override")
+	}
+}
+
+func TestComponent(t *testing.T) {
+	read := initCapture(t, Config{})
+
+	Info(Component("db"), "query done", map[string]interface{}{"rows": 3})
+	Warn(Component("db"), Component("cache"), "miss")
+	Info("untagged")
+
+	entries := read()
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	if entries[0].Component != "db" || len(entries[0].Args) != 2 {
+		t.Errorf("expected the db component outside args, got %q %v", entries[0].Component, entries[0].Args)
+	}
+	if entries[1].Component != "cache" {
+		t.Errorf("expected the last component to win, got %q", entries[1].Component)
+	}
+	if entries[2].Component != "" {
+		t.Errorf("expected no component without the option, got %q", entries[2].Component)
+	}
+}
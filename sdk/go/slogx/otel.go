@@ -0,0 +1,105 @@
+package slogx
+
+import (
+	"strconv"
+	"time"
+)
+
+// Format selects the JSON shape of entries written to Config.Console and
+// the log file. Viewers always receive the native shape.
+type Format string
+
+const (
+	// FormatNative writes entries as LogEntry, the shape viewers read.
+	FormatNative Format = ""
+	// FormatOTEL writes entries as OpenTelemetry log records, for pipelines
+	// that already ingest them.
+	FormatOTEL Format = "OTEL"
+)
+
+// otelSeverity maps levels to OpenTelemetry severity numbers, using the
+// first number of each range.
+var otelSeverity = map[LogLevel]int{
+	TRACE: 1,
+	DEBUG: 5,
+	INFO:  9,
+	WARN:  13,
+	ERROR: 17,
+}
+
+// otelRecord is a LogEntry in the OpenTelemetry log data model. The
+// timestamp is a string of nanoseconds, as in OTLP/JSON.
+type otelRecord struct {
+	TimeUnixNano   string                 `json:"timeUnixNano"`
+	SeverityNumber int                    `json:"severityNumber"`
+	SeverityText   string                 `json:"severityText"`
+	Body           interface{}            `json:"body"`
+	Attributes     map[string]interface{} `json:"attributes"`
+	Resource       map[string]interface{} `json:"resource"`
+}
+
+// formatted returns entry in the shape Config.Format asks for.
+func (s *SlogX) formatted(entry LogEntry) interface{} {
+	if s.config.Format == FormatOTEL {
+		return s.otelRecord(&entry)
+	}
+	return entry
+}
+
+// otelRecord converts entry. The message (the first arg when it's a
+// string) becomes the body and the remaining args the `args` attribute;
+// metadata becomes attributes, except the service, which moves to the
+// resource as `service.name`.
+func (s *SlogX) otelRecord(entry *LogEntry) otelRecord {
+	ts, err := time.Parse(time.RFC3339Nano, entry.Timestamp)
+	if err != nil {
+		ts = time.Now()
+	}
+
+	var body interface{}
+	var rest []interface{}
+	switch {
+	case entry.Message != "" || len(entry.Data) > 0 || len(entry.Errors) > 0:
+		body = entry.Message
+		rest = append(append(rest, entry.Data...), entry.Errors...)
+	case len(entry.Args) > 0:
+		if msg, ok := entry.Args[0].(string); ok {
+			body, rest = msg, entry.Args[1:]
+		} else {
+			rest = entry.Args
+		}
+	}
+
+	serviceKey := s.metaKey("service")
+	attributes := make(map[string]interface{}, len(entry.Metadata)+4)
+	for k, v := range entry.Metadata {
+		if k != serviceKey {
+			attributes[k] = v
+		}
+	}
+	attributes["log.record.uid"] = entry.ID
+	if entry.Seq != 0 {
+		attributes["slogx.seq"] = entry.Seq
+	}
+	if entry.ParentID != "" {
+		attributes["slogx.parentId"] = entry.ParentID
+	}
+	if entry.Component != "" {
+		attributes["slogx.component"] = entry.Component
+	}
+	if len(rest) > 0 {
+		attributes["args"] = rest
+	}
+	if entry.Stacktrace != "" {
+		attributes["stacktrace"] = entry.Stacktrace
+	}
+
+	return otelRecord{
+		TimeUnixNano:   strconv.FormatInt(ts.UnixNano(), 10),
+		SeverityNumber: otelSeverity[entry.Level],
+		SeverityText:   string(entry.Level),
+		Body:           body,
+		Attributes:     attributes,
+		Resource:       map[string]interface{}{"service.name": entry.Metadata[serviceKey]},
+	}
+}
@@ -0,0 +1,56 @@
+package slogx
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestFormatOTEL(t *testing.T) {
+	var out bytes.Buffer
+	initCapture(t, Config{Console: &out, Format: FormatOTEL, MinLevel: TRACE, ServiceName: "billing"})
+
+	levels := []LogLevel{TRACE, DEBUG, INFO, WARN, ERROR}
+	for _, level := range levels {
+		Log(level, "charge", map[string]interface{}{"amount": 12})
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != len(levels) {
+		t.Fatalf("expected %d records, got %d", len(levels), len(lines))
+	}
+	expectedSeverity := map[LogLevel]float64{TRACE: 1, DEBUG: 5, INFO: 9, WARN: 13, ERROR: 17}
+	for i, line := range lines {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatal(err)
+		}
+		level := levels[i]
+		if record["severityNumber"] != expectedSeverity[level] || record["severityText"] != string(level) {
+			t.Errorf("%s: expected severity %v, got %v %v", level, expectedSeverity[level], record["severityNumber"], record["severityText"])
+		}
+		if record["body"] != "charge" {
+			t.Errorf("%s: expected the message as body, got %v", level, record["body"])
+		}
+		if _, err := strconv.ParseInt(record["timeUnixNano"].(string), 10, 64); err != nil {
+			t.Errorf("%s: expected nanoseconds as a string, got %v", level, record["timeUnixNano"])
+		}
+
+		attributes := record["attributes"].(map[string]interface{})
+		if attributes["file"] != "otel_test.go" || attributes["lang"] != "go" {
+			t.Errorf("%s: expected metadata in attributes, got %v", level, attributes)
+		}
+		if _, ok := attributes["service"]; ok {
+			t.Errorf("%s: expected the service only on the resource, got %v", level, attributes)
+		}
+		args := attributes["args"].([]interface{})
+		if len(args) != 1 || args[0].(map[string]interface{})["amount"] != float64(12) {
+			t.Errorf("%s: expected the remaining args as an attribute, got %v", level, attributes["args"])
+		}
+		if resource := record["resource"].(map[string]interface{}); resource["service.name"] != "billing" {
+			t.Errorf("%s: expected service.name on the resource, got %v", level, resource)
+		}
+	}
+}
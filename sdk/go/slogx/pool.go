@@ -0,0 +1,98 @@
+package slogx
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+)
+
+// Pools for the per-entry allocations on the hot log path. Everything taken
+// from a pool is only used until the entry has been written synchronously by
+// emit, after which it is reset and returned.
+
+// maxPooledArgs keeps unusually large arg slices from being retained.
+const maxPooledArgs = 64
+
+var metadataPool = sync.Pool{
+	New: func() interface{} { return make(map[string]interface{}, 8) },
+}
+
+var argsPool = sync.Pool{
+	New: func() interface{} {
+		args := make([]interface{}, 0, 8)
+		return &args
+	},
+}
+
+// entryEncoder pairs a reusable buffer with an encoder writing into it.
+type entryEncoder struct {
+	buf bytes.Buffer
+	enc *json.Encoder
+}
+
+var encoderPool = sync.Pool{
+	New: func() interface{} {
+		e := &entryEncoder{}
+		e.enc = json.NewEncoder(&e.buf)
+		return e
+	},
+}
+
+func getMetadata() map[string]interface{} {
+	return metadataPool.Get().(map[string]interface{})
+}
+
+func putMetadata(m map[string]interface{}) {
+	for k := range m {
+		delete(m, k)
+	}
+	metadataPool.Put(m)
+}
+
+// getArgs returns a zeroed slice of length n, reusing a pooled backing array
+// when it is large enough.
+func getArgs(n int) []interface{} {
+	args := *argsPool.Get().(*[]interface{})
+	if cap(args) < n {
+		return make([]interface{}, n)
+	}
+	return args[:n]
+}
+
+func putArgs(args []interface{}) {
+	if cap(args) > maxPooledArgs {
+		return
+	}
+	for i := range args {
+		args[i] = nil
+	}
+	args = args[:0]
+	argsPool.Put(&args)
+}
+
+// marshalPooled encodes v into a pooled buffer, honoring the JSON options in
+// config, and passes the result to fn. The bytes are only valid for the
+// duration of fn.
+func marshalPooled(v interface{}, config *Config, fn func(payload []byte)) error {
+	return marshalPooledIndent(v, config, config.JSONIndent, fn)
+}
+
+// prettyIndent is the indent used for Config.PrettyJSON.
+const prettyIndent = "  "
+
+// marshalPooledIndent is marshalPooled with an explicit indent.
+func marshalPooledIndent(v interface{}, config *Config, indent string, fn func(payload []byte)) error {
+	e := encoderPool.Get().(*entryEncoder)
+	defer encoderPool.Put(e)
+
+	e.buf.Reset()
+	e.enc.SetEscapeHTML(!config.DisableHTMLEscape)
+	e.enc.SetIndent("", indent)
+	if err := e.enc.Encode(v); err != nil {
+		return err
+	}
+	// Encode terminates each value with a newline; frames don't want it.
+	payload := e.buf.Bytes()
+	fn(payload[:len(payload)-1])
+	return nil
+}
@@ -0,0 +1,182 @@
+package slogx
+
+import (
+	"encoding/json"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// protocolVersion is announced in the handshake so viewers can detect
+// incompatible servers.
+const protocolVersion = 1
+
+// serverFeatures lists the optional behaviors a client may request, either
+// in its handshake reply or, for resume, as a `lastSeq` query parameter.
+var serverFeatures = []string{"minLevel", "services", "components", "resume", "wireFormat"}
+
+// handshake is the first frame a WebSocket client receives after upgrade.
+// Its type, "hello", sets it apart from log entries. It also carries the
+// process details that stay fixed for the connection, so entries don't
+// have to repeat them.
+type handshake struct {
+	Type     string   `json:"type"`
+	Slogx    int      `json:"slogx"`
+	Service  string   `json:"service"`
+	Version  string   `json:"version,omitempty"`
+	Features []string `json:"features"`
+	// WireFormats are the entry encodings a client may pick with
+	// `wireFormat`; the first is the server's default.
+	WireFormats []WireFormat `json:"wireFormats"`
+	// MetadataPrefix is Config.MetadataPrefix, which clients prepend to
+	// built-in metadata keys such as file and line.
+	MetadataPrefix string `json:"metadataPrefix,omitempty"`
+
+	Hostname  string `json:"hostname,omitempty"`
+	PID       int    `json:"pid"`
+	GoVersion string `json:"goVersion"`
+	NumCPU    int    `json:"numCPU"`
+	StartedAt string `json:"startedAt"`
+}
+
+// clientPreferences is what a client may send back to tune its own stream.
+// Unknown fields are ignored for forward compatibility.
+type clientPreferences struct {
+	MinLevel LogLevel `json:"minLevel,omitempty"`
+	// Services limits the stream to entries from these services. An empty
+	// list means all services.
+	Services []string `json:"services,omitempty"`
+	// Components limits the stream to entries tagged with these components.
+	// Untagged entries are left out too. An empty list means all entries.
+	Components []string `json:"components,omitempty"`
+	// WireFormat switches entry frames to another encoding, e.g. "msgpack"
+	// for binary frames. The handshake and system frames stay JSON text.
+	WireFormat WireFormat `json:"wireFormat,omitempty"`
+}
+
+func (s *SlogX) handshakeFrame() []byte {
+	hostname, _ := os.Hostname()
+	data, _ := json.Marshal(handshake{
+		Type:           "hello",
+		Slogx:          protocolVersion,
+		Service:        s.service(),
+		Version:        s.version,
+		Features:       serverFeatures,
+		WireFormats:    s.wireFormats(),
+		MetadataPrefix: s.config.MetadataPrefix,
+		Hostname:       hostname,
+		PID:            os.Getpid(),
+		GoVersion:      runtime.Version(),
+		NumCPU:         runtime.NumCPU(),
+		StartedAt:      s.startedAt.Format(time.RFC3339Nano),
+	})
+	return data
+}
+
+// wireFormats lists the supported wire formats, the default first.
+func (s *SlogX) wireFormats() []WireFormat {
+	formats := []WireFormat{s.config.wireFormat()}
+	for _, f := range wireFormats {
+		if f != formats[0] {
+			formats = append(formats, f)
+		}
+	}
+	return formats
+}
+
+// applyPreferences updates a client's stream from a raw handshake reply.
+// Malformed messages and unknown values leave the current settings alone.
+func (c *client) applyPreferences(data []byte) {
+	var prefs clientPreferences
+	if err := json.Unmarshal(data, &prefs); err != nil {
+		return
+	}
+
+	c.prefsMu.Lock()
+	defer c.prefsMu.Unlock()
+
+	if _, ok := levelRank[prefs.MinLevel]; ok {
+		c.prefs.MinLevel = prefs.MinLevel
+	}
+	// A missing field keeps the current filter; an empty list clears it.
+	if prefs.Services != nil {
+		c.prefs.Services = prefs.Services
+	}
+	if prefs.Components != nil {
+		c.prefs.Components = prefs.Components
+	}
+	if validWireFormat(prefs.WireFormat) {
+		c.prefs.WireFormat = prefs.WireFormat
+	}
+}
+
+// binary reports whether entries go to this client as MessagePack, given
+// the server's default format. Only clients that can take binary frames
+// (WebSocket) switch.
+func (c *client) binary(defaultFormat WireFormat) bool {
+	if c.sendBinary == nil {
+		return false
+	}
+	c.prefsMu.RLock()
+	defer c.prefsMu.RUnlock()
+	format := c.prefs.WireFormat
+	if format == "" {
+		format = defaultFormat
+	}
+	return format == WireMsgPack
+}
+
+// wants reports whether an entry from service passes this client's
+// preferences.
+func (c *client) wants(entry *LogEntry, service string) bool {
+	c.prefsMu.RLock()
+	defer c.prefsMu.RUnlock()
+
+	if c.prefs.MinLevel != "" && levelRank[entry.Level] < levelRank[c.prefs.MinLevel] {
+		return false
+	}
+	return listed(c.prefs.Services, service) && listed(c.prefs.Components, entry.Component)
+}
+
+// listed reports whether value is in filter; an empty filter allows any.
+func listed(filter []string, value string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, v := range filter {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// buildVersion derives the application version from the binary's build
+// info: the main module version for `go install`ed binaries, otherwise the
+// VCS revision stamped at build time. It is empty when neither is known.
+func buildVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		return v
+	}
+	var revision, modified string
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value
+		}
+	}
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	if revision != "" && modified == "true" {
+		revision += "-dirty"
+	}
+	return revision
+}
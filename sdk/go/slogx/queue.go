@@ -0,0 +1,120 @@
+package slogx
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// systemFrame builds a WARN entry generated by slogx itself rather than the
+// application. Its metadata carries `system: true` so viewers can style it
+// apart, plus the given details.
+func (s *SlogX) systemFrame(message string, details map[string]interface{}) []byte {
+	metadata := map[string]interface{}{
+		s.metaKey("lang"):    "go",
+		s.metaKey("service"): s.service(),
+		s.metaKey("system"):  true,
+	}
+	for k, v := range details {
+		metadata[s.metaKey(k)] = v
+	}
+	data, _ := json.Marshal(LogEntry{
+		ID:        generateID(),
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+		Level:     WARN,
+		Severity:  levelSeverity[WARN],
+		Args:      []interface{}{message},
+		Metadata:  metadata,
+	})
+	return data
+}
+
+// startQueue gives c a bounded send queue drained by its own goroutine, so
+// a slow viewer can't hold up logging. Frames that don't fit are dropped
+// and reported to the viewer once it catches up, even if nothing else is
+// logged.
+func (s *SlogX) startQueue(c *client, size int) {
+	c.queue = make(chan queuedFrame, size)
+	c.dropSignal = make(chan struct{}, 1)
+	c.stop = make(chan struct{})
+	go s.drain(c)
+}
+
+// queuedFrame is a frame waiting in a client's queue. When written is set,
+// the drain goroutine reports the write's result on it.
+type queuedFrame struct {
+	payload []byte
+	binary  bool
+	written chan error
+}
+
+// enqueue hands a frame to c's queue without blocking, counting it as
+// dropped when the queue is full.
+func (c *client) enqueue(f queuedFrame) bool {
+	select {
+	case c.queue <- f:
+		return true
+	default:
+		c.dropped.Add(1)
+		// Wake the drain goroutine in case nothing else is logged.
+		select {
+		case c.dropSignal <- struct{}{}:
+		default:
+		}
+		return false
+	}
+}
+
+func (s *SlogX) drain(c *client) {
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-c.dropSignal:
+			// Queued frames report the drops themselves; an idle queue
+			// reports them now, as no frame may follow.
+			if len(c.queue) > 0 {
+				continue
+			}
+			if err := s.reportDrops(c); err != nil {
+				c.drop(err)
+				return
+			}
+		case f := <-c.queue:
+			// Report drops ahead of the next frame that does get through.
+			if err := s.reportDrops(c); err != nil {
+				if f.written != nil {
+					f.written <- err
+				}
+				c.drop(err)
+				return
+			}
+			err := c.writeFrame(f.payload, f.binary)
+			if f.written != nil {
+				f.written <- err
+			}
+			if err != nil {
+				c.drop(err)
+				return
+			}
+		}
+	}
+}
+
+// reportDrops sends c a notice of the frames dropped since the last one,
+// if any.
+func (s *SlogX) reportDrops(c *client) error {
+	n := c.dropped.Swap(0)
+	if n == 0 {
+		return nil
+	}
+	return c.write(s.systemFrame(fmt.Sprintf("slogx dropped %d entries", n), map[string]interface{}{"dropped": n}))
+}
+
+// drop disconnects the client after a failed or timed out write, which
+// leaves its stream in an unknown state. The viewer can reconnect.
+func (c *client) drop(err error) {
+	if err != errClientRemoved {
+		c.close()
+	}
+}
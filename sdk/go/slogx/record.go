@@ -0,0 +1,125 @@
+package slogx
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// recorder receives a copy of every frame sent to viewers; see Record.
+type recorder struct {
+	w io.Writer
+}
+
+// Record writes a copy of every frame broadcast to viewers to w until the
+// returned stop function is called, e.g. to save a debugging session for
+// Replay. Each frame is written as a header line `<unix nanos> <length>`
+// followed by the frame itself and a newline, so indented frames survive.
+// Recording stops on its own if a write to w fails. Writes happen on the
+// logging goroutine, so w should be fast (wrap files in a bufio.Writer).
+func Record(w io.Writer) (stop func()) {
+	s := getInstance()
+	r := &recorder{w: w}
+
+	s.recordMu.Lock()
+	if s.recorders == nil {
+		s.recorders = make(map[*recorder]bool)
+	}
+	s.recorders[r] = true
+	s.recordMu.Unlock()
+
+	return func() {
+		s.recordMu.Lock()
+		delete(s.recorders, r)
+		s.recordMu.Unlock()
+	}
+}
+
+// recording reports whether any recorder is attached.
+func (s *SlogX) recording() bool {
+	s.recordMu.Lock()
+	defer s.recordMu.Unlock()
+	return len(s.recorders) > 0
+}
+
+// record writes payload to every recorder. Holding recordMu keeps frames in
+// emit order.
+func (s *SlogX) record(payload []byte) {
+	s.recordMu.Lock()
+	defer s.recordMu.Unlock()
+
+	header := fmt.Sprintf("%d %d\n", time.Now().UnixNano(), len(payload))
+	for r := range s.recorders {
+		if _, err := io.WriteString(r.w, header); err != nil {
+			delete(s.recorders, r)
+			continue
+		}
+		if _, err := r.w.Write(payload); err != nil {
+			delete(s.recorders, r)
+			continue
+		}
+		if _, err := io.WriteString(r.w, "\n"); err != nil {
+			delete(s.recorders, r)
+		}
+	}
+}
+
+// maxReplayFrameSize bounds the frame size Replay trusts from a recording's
+// header, so a corrupt one can't make it allocate without limit.
+const maxReplayFrameSize = 64 << 20
+
+// Replay broadcasts frames saved by Record to the viewers connected now,
+// honoring each viewer's level and service filters. With realtime set it
+// waits between frames as long as passed between them when recorded. It
+// stops early when ctx is done. Frames keep their recorded seq and are not
+// written to the console, log file or replay buffer.
+func Replay(ctx context.Context, r io.Reader, realtime bool) error {
+	s := getInstance()
+	br := bufio.NewReader(r)
+
+	var last int64
+	for {
+		var at int64
+		var size int
+		if _, err := fmt.Fscanf(br, "%d %d\n", &at, &size); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("slogx: reading recorded frame header: %w", err)
+		}
+		if size < 0 || size > maxReplayFrameSize {
+			return fmt.Errorf("slogx: recorded frame size %d out of range", size)
+		}
+		frame := make([]byte, size+1)
+		if _, err := io.ReadFull(br, frame); err != nil {
+			return fmt.Errorf("slogx: reading recorded frame: %w", err)
+		}
+		if frame[size] != '\n' {
+			return fmt.Errorf("slogx: recorded frame of %d bytes not followed by a newline", size)
+		}
+		payload := frame[:size]
+
+		if realtime && last != 0 && at > last {
+			timer := time.NewTimer(time.Duration(at - last))
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			}
+		}
+		last = at
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var entry LogEntry
+		if err := json.Unmarshal(payload, &entry); err != nil {
+			return fmt.Errorf("slogx: decoding recorded frame: %w", err)
+		}
+		s.broadcast(&entry, payload, entryOptions{})
+	}
+}
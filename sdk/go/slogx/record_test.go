@@ -0,0 +1,100 @@
+package slogx
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordReplay(t *testing.T) {
+	s, srv := startTestServer(t)
+	s.config.JSONIndent = "  "
+
+	var recorded bytes.Buffer
+	stop := Record(&recorded)
+	Info("first", map[string]interface{}{"n": 1})
+	Warn("second")
+	Error("third")
+	stop()
+	Info("after stop")
+
+	// Indented frames span lines, but only their opening brace is unindented.
+	frames := 0
+	for _, line := range strings.Split(recorded.String(), "\n") {
+		if strings.HasPrefix(line, "{") {
+			frames++
+		}
+	}
+	if frames != 3 {
+		t.Fatalf("expected 3 recorded frames, got %d:\n%s", frames, recorded.String())
+	}
+
+	conn := dialClient(t, wsURL(srv))
+	waitForClients(t, s, 1)
+
+	saved := recorded.Bytes()
+	if err := Replay(context.Background(), bytes.NewReader(saved), false); err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []string{"first", "second", "third"} {
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("failed to read replayed frame %d: %v", i, err)
+		}
+		if !bytes.Contains(saved, append(data, '\n')) {
+			t.Errorf("frame %d differs from the recording:\n%s", i, data)
+		}
+		if !bytes.Contains(data, []byte(`"`+want+`"`)) {
+			t.Errorf("frame %d: expected %q, got %s", i, want, data)
+		}
+	}
+}
+
+func TestReplay_Realtime(t *testing.T) {
+	startTestServer(t)
+
+	var recorded bytes.Buffer
+	stop := Record(&recorded)
+	Info("tick")
+	time.Sleep(50 * time.Millisecond)
+	Info("tock")
+	stop()
+
+	start := time.Now()
+	if err := Replay(context.Background(), bytes.NewReader(recorded.Bytes()), true); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("expected replay to keep the recorded gap, took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := Replay(ctx, bytes.NewReader(recorded.Bytes()), true); err != context.Canceled {
+		t.Errorf("expected replay to stop on a done context, got %v", err)
+	}
+}
+
+func TestReplay_CorruptHeaders(t *testing.T) {
+	startTestServer(t)
+
+	cases := map[string]string{
+		"negative size":    "1 -5\n{}\n",
+		"oversized":        "1 " + strconv.Itoa(maxReplayFrameSize+1) + "\n{}\n",
+		"wrong terminator": "1 2\n{}x",
+		"short frame":      "1 10\n{}\n",
+	}
+	for name, input := range cases {
+		if err := Replay(context.Background(), strings.NewReader(input), false); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	if err := Replay(context.Background(), strings.NewReader("1 2\n{}\n"), false); err != nil {
+		t.Errorf("expected a well-formed frame to replay, got %v", err)
+	}
+}
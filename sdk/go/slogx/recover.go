@@ -0,0 +1,59 @@
+package slogx
+
+import (
+	"runtime"
+	"strings"
+)
+
+// Recover logs a panic as an ERROR entry and stops it from unwinding any
+// further. It must be deferred directly, e.g.
+//
+//	defer slogx.Recover()
+//
+// The entry's caller and stack start at the code that panicked rather than
+// at the deferred call.
+func Recover() {
+	if r := recover(); r != nil {
+		LogPanic(r)
+	}
+}
+
+// RecoverRepanic is Recover for code that should still crash: it logs the
+// panic the same way and then panics again with the same value.
+func RecoverRepanic() {
+	if r := recover(); r != nil {
+		LogPanic(r)
+		panic(r)
+	}
+}
+
+// LogPanic logs r, a value just recovered from a panic, as an ERROR entry
+// attributed to the panic site. It is for wrappers that have to call
+// recover themselves, since recover only works in the deferred function,
+// and must be called from within that function.
+func LogPanic(r interface{}) {
+	log(panicDepth(), ERROR, "panic", r)
+}
+
+// panicDepth counts the frames from its caller's caller up to the code that
+// panicked, which sits just below the runtime's panic frames. Outside of a
+// panic it returns 0.
+func panicDepth() int {
+	pc := make([]uintptr, 64)
+	// Skip runtime.Callers, panicDepth and LogPanic.
+	frames := runtime.CallersFrames(pc[:runtime.Callers(3, pc)])
+	depth := 0
+	inRuntime := false
+	for {
+		frame, more := frames.Next()
+		if strings.HasPrefix(frame.Function, "runtime.") {
+			inRuntime = true
+		} else if inRuntime {
+			return depth
+		}
+		depth++
+		if !more {
+			return 0
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package slogx
+
+import (
+	"strings"
+	"testing"
+)
+
+func writeNilMap() {
+	defer Recover()
+	var m map[string]int
+	m["boom"] = 1
+}
+
+func TestRecover(t *testing.T) {
+	read := initCapture(t, Config{})
+
+	func() {
+		defer Recover()
+		panic("boom")
+	}()
+	writeNilMap()
+
+	entries := read()
+	if len(entries) != 2 {
+		t.Fatalf("expected an entry per panic, got %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.Level != ERROR || entry.Args[0] != "panic" || entry.Args[1] != "boom" {
+		t.Errorf("expected an ERROR panic entry with the value, got %v %v", entry.Level, entry.Args)
+	}
+	if entry.Metadata["file"] != "recover_test.go" || entry.Metadata["func"] != "slogx.TestRecover.func1" {
+		t.Errorf("expected the panic site as caller, got %v in %v", entry.Metadata["func"], entry.Metadata["file"])
+	}
+	if !strings.HasPrefix(entry.Stacktrace, "at github.com/binhonglee/slogx/sdk/go/slogx.TestRecover.func1 ") {
+		t.Errorf("expected the stack to start at the panic site, got:\n%s", entry.Stacktrace)
+	}
+
+	runtimeErr := entries[1]
+	if msg, _ := runtimeErr.Args[1].(map[string]interface{})["message"].(string); !strings.Contains(msg, "nil map") {
+		t.Errorf("expected the runtime error's message, got %v", runtimeErr.Args[1])
+	}
+	if runtimeErr.Metadata["func"] != "slogx.writeNilMap" {
+		t.Errorf("expected runtime panics to be attributed to the faulting code, got %v", runtimeErr.Metadata["func"])
+	}
+}
+
+func TestRecoverRepanic(t *testing.T) {
+	read := initCapture(t, Config{})
+
+	var repanicked interface{}
+	func() {
+		defer func() { repanicked = recover() }()
+		defer RecoverRepanic()
+		panic("boom")
+	}()
+
+	if repanicked != "boom" {
+		t.Errorf("expected the panic to continue with its value, got %v", repanicked)
+	}
+	if entries := read(); len(entries) != 1 || entries[0].Args[1] != "boom" {
+		t.Errorf("expected the panic to be logged before repanicking, got %v", entries)
+	}
+}
+
+func TestRecover_NoPanic(t *testing.T) {
+	read := initCapture(t, Config{})
+
+	func() {
+		defer Recover()
+	}()
+
+	if entries := read(); len(entries) != 0 {
+		t.Errorf("expected nothing logged without a panic, got %d entries", len(entries))
+	}
+}
@@ -0,0 +1,124 @@
+package slogx
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// repeatState is the run of identical entries being collapsed.
+type repeatState struct {
+	mu sync.Mutex
+	// key identifies the last emitted entry; see repeatKey.
+	key     []byte
+	firstID string
+	level   LogLevel
+	service string
+	count   int
+	// emitted is closed once the run's first entry has been emitted.
+	emitted chan struct{}
+}
+
+// repeatKey is what makes two entries identical for CollapseRepeats: all
+// fields except id, seq, timestamp and metadata.ingestedAt. It returns nil
+// when the entry can't be marshaled, which never matches.
+func (s *SlogX) repeatKey(entry *LogEntry) []byte {
+	metadata := entry.Metadata
+	if ingestedAt := s.metaKey("ingestedAt"); metadata[ingestedAt] != nil {
+		metadata = make(map[string]interface{}, len(entry.Metadata))
+		for k, v := range entry.Metadata {
+			if k != ingestedAt {
+				metadata[k] = v
+			}
+		}
+	}
+	key, err := json.Marshal(struct {
+		Level       LogLevel
+		Args        []interface{}
+		Stacktrace  string
+		StackFrames []StackFrame
+		Metadata    map[string]interface{}
+	}{entry.Level, entry.Args, entry.Stacktrace, entry.StackFrames, metadata})
+	if err != nil {
+		return nil
+	}
+	return key
+}
+
+// emitCollapsed emits entry unless it repeats the previous one. The run is
+// decided under the lock but emitted after it, so a slow sink doesn't hold
+// up logging; a repeat note still waits for the first entry of its run.
+func (s *SlogX) emitCollapsed(entry LogEntry, opts entryOptions) (delivered int) {
+	key := s.repeatKey(&entry)
+
+	r := &s.repeats
+	r.mu.Lock()
+	if key != nil && bytes.Equal(key, r.key) {
+		r.count++
+		r.mu.Unlock()
+		return 0
+	}
+	note, after := s.endRunLocked()
+	r.key, r.firstID, r.level = key, entry.ID, entry.Level
+	// The note goes to the same viewers as the run, which may be logged
+	// under a per-entry service.
+	r.service, _ = entry.Metadata[s.metaKey("service")].(string)
+	emitted := make(chan struct{})
+	r.emitted = emitted
+
+	entry.Seq = s.seq.Add(1)
+	opts.chain.linkTo(&entry)
+	r.mu.Unlock()
+
+	defer close(emitted)
+	s.emitNote(note, after)
+	return s.emit(entry, opts)
+}
+
+// flushRepeats ends the current run, emitting its repeat note if any.
+func (s *SlogX) flushRepeats() {
+	s.repeats.mu.Lock()
+	note, after := s.endRunLocked()
+	s.repeats.key = nil
+	s.repeats.mu.Unlock()
+	s.emitNote(note, after)
+}
+
+// endRunLocked ends the current run and returns its repeat note, nil when
+// nothing repeated, along with the channel closed once the run's first
+// entry has been emitted.
+func (s *SlogX) endRunLocked() (note *LogEntry, after <-chan struct{}) {
+	r := &s.repeats
+	if r.count == 0 {
+		return nil, nil
+	}
+	note = &LogEntry{
+		ID:        generateID(),
+		Seq:       s.seq.Add(1),
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+		Level:     r.level,
+		Severity:  levelSeverity[r.level],
+		Args:      []interface{}{fmt.Sprintf("(repeated x%d)", r.count)},
+		Metadata: map[string]interface{}{
+			s.metaKey("lang"):     "go",
+			s.metaKey("service"):  r.service,
+			s.metaKey("repeated"): r.count,
+			s.metaKey("repeatOf"): r.firstID,
+		},
+	}
+	r.count = 0
+	return note, r.emitted
+}
+
+// emitNote emits a repeat note once the entry it follows has been emitted.
+func (s *SlogX) emitNote(note *LogEntry, after <-chan struct{}) {
+	if note == nil {
+		return
+	}
+	if after != nil {
+		<-after
+	}
+	s.emit(*note, entryOptions{})
+}
@@ -0,0 +1,89 @@
+package slogx
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func logFlapping(msg string) { Warn(msg, map[string]interface{}{"healthy": false}) }
+
+func TestCollapseRepeats(t *testing.T) {
+	read := initCapture(t, Config{CollapseRepeats: true})
+
+	for i := 0; i < 4; i++ {
+		logFlapping("upstream down")
+	}
+	logFlapping("upstream recovered")
+	for i := 0; i < 2; i++ {
+		logFlapping("upstream down")
+	}
+	getInstance().flushRepeats()
+
+	entries := read()
+	want := []string{"upstream down", "(repeated x3)", "upstream recovered", "upstream down", "(repeated x1)"}
+	if len(entries) != len(want) {
+		t.Fatalf("expected %d entries, got %d: %+v", len(want), len(entries), entries)
+	}
+	for i, msg := range want {
+		if entries[i].Args[0] != msg {
+			t.Errorf("entry %d: expected %q, got %v", i, msg, entries[i].Args[0])
+		}
+		if i > 0 && entries[i].Seq != entries[i-1].Seq+1 {
+			t.Errorf("entry %d: expected seq without gaps, got %d after %d", i, entries[i].Seq, entries[i-1].Seq)
+		}
+	}
+
+	note := entries[1]
+	if note.Level != WARN || note.Metadata["repeated"] != float64(3) || note.Metadata["repeatOf"] != entries[0].ID {
+		t.Errorf("expected the note to count 3 repeats of the first entry, got %+v", note)
+	}
+	if entries[4].Metadata["repeatOf"] != entries[3].ID {
+		t.Errorf("expected the counter to restart after a different entry, got %+v", entries[4])
+	}
+}
+
+// gateWriter blocks its first write until release is closed.
+type gateWriter struct {
+	once    sync.Once
+	started chan struct{}
+	release chan struct{}
+}
+
+func (w *gateWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() {
+		close(w.started)
+		<-w.release
+	})
+	return len(p), nil
+}
+
+func TestCollapseRepeats_SlowSinkDoesNotBlockRepeats(t *testing.T) {
+	console := &gateWriter{started: make(chan struct{}), release: make(chan struct{})}
+	read := initCapture(t, Config{CollapseRepeats: true, Console: console})
+
+	// Both calls go through one closure so they log from the same caller.
+	flap := func(done chan struct{}) {
+		defer close(done)
+		logFlapping("upstream down")
+	}
+	first, repeated := make(chan struct{}), make(chan struct{})
+	go flap(first)
+	<-console.started
+	go flap(repeated)
+	select {
+	case <-repeated:
+	case <-time.After(2 * time.Second):
+		close(console.release)
+		t.Fatal("expected a repeat to be counted while the first entry is still being written")
+	}
+
+	close(console.release)
+	<-first
+	getInstance().flushRepeats()
+
+	entries := read()
+	if len(entries) != 2 || entries[0].Args[0] != "upstream down" || entries[1].Args[0] != "(repeated x1)" {
+		t.Fatalf("expected the entry then its repeat note, got %+v", entries)
+	}
+}
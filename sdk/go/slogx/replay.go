@@ -0,0 +1,144 @@
+package slogx
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// bufferedEntry is an already-marshaled frame kept for replay.
+type bufferedEntry struct {
+	seq     uint64
+	payload []byte
+}
+
+// replayBuffer keeps the most recent frames so a reconnecting viewer can
+// pick up where it left off.
+type replayBuffer struct {
+	mu      sync.Mutex
+	entries []bufferedEntry
+	next    int
+	full    bool
+}
+
+func newReplayBuffer(size int) *replayBuffer {
+	return &replayBuffer{entries: make([]bufferedEntry, size)}
+}
+
+// add stores a copy of payload, evicting the oldest frame when full.
+func (b *replayBuffer) add(seq uint64, payload []byte) {
+	frame := bufferedEntry{seq: seq, payload: append([]byte(nil), payload...)}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entries[b.next] = frame
+	b.next++
+	if b.next == len(b.entries) {
+		b.next = 0
+		b.full = true
+	}
+}
+
+// since returns the buffered frames with a sequence number above lastSeq,
+// in buffer order, and the lowest sequence number still buffered (0 when
+// the buffer is empty).
+func (b *replayBuffer) since(lastSeq uint64) (frames []bufferedEntry, oldest uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	start, n := 0, b.next
+	if b.full {
+		start, n = b.next, len(b.entries)
+	}
+	for i := 0; i < n; i++ {
+		e := b.entries[(start+i)%len(b.entries)]
+		if oldest == 0 || e.seq < oldest {
+			oldest = e.seq
+		}
+		if e.seq > lastSeq {
+			frames = append(frames, e)
+		}
+	}
+	return frames, oldest
+}
+
+// resumeFrom reads the `lastSeq` query parameter a reconnecting viewer sends
+// with its upgrade request.
+func resumeFrom(r *http.Request) (lastSeq uint64, ok bool) {
+	raw := r.URL.Query().Get("lastSeq")
+	if raw == "" {
+		return 0, false
+	}
+	lastSeq, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return lastSeq, true
+}
+
+// replayTo registers c and sends it every buffered entry after lastSeq. The
+// client's write lock is held from registration until the replay is
+// written, so live entries queue up behind the replay instead of
+// overtaking it. Entries that were replayed are skipped when they are
+// broadcast.
+func (s *SlogX) replayTo(c *client, lastSeq uint64) {
+	c.writeMu.Lock()
+
+	s.clientsMu.Lock()
+	current := s.seq.Load()
+	if lastSeq > current {
+		// The sequence restarted, so the viewer's position refers to an
+		// earlier process. Everything buffered is new to it.
+		lastSeq = 0
+	}
+	var frames []bufferedEntry
+	var oldest uint64
+	if s.replay != nil {
+		frames, oldest = s.replay.since(lastSeq)
+	}
+	c.replayed = make(map[uint64]bool, len(frames))
+	for _, f := range frames {
+		c.replayed[f.seq] = true
+	}
+	s.registerLocked(c)
+	s.clientsMu.Unlock()
+
+	// Entries between lastSeq and the oldest buffered frame are gone.
+	var missed uint64
+	switch {
+	case s.replay == nil:
+		missed = current - lastSeq
+	case oldest > lastSeq+1:
+		missed = oldest - lastSeq - 1
+	}
+	// The replay goes out in the same wire format as live entries.
+	binary := c.binary(s.config.wireFormat())
+	send := func(payload []byte) {
+		if binary {
+			packed, err := msgpackFromJSON(payload)
+			if err != nil {
+				return
+			}
+			payload = packed
+		}
+		c.writeFrameLocked(payload, binary)
+	}
+	if missed > 0 {
+		send(s.truncatedFrame(lastSeq, missed))
+	}
+	for _, f := range frames {
+		send(f.payload)
+	}
+	c.writeMu.Unlock()
+}
+
+// truncatedFrame is a system entry telling a resuming viewer that part of
+// the gap could not be replayed.
+func (s *SlogX) truncatedFrame(lastSeq, missed uint64) []byte {
+	return s.systemFrame(
+		fmt.Sprintf("slogx: %d entries after seq %d are no longer buffered", missed, lastSeq),
+		map[string]interface{}{"truncated": true, "lastSeq": lastSeq, "missed": missed},
+	)
+}
@@ -0,0 +1,49 @@
+package slogx
+
+import (
+	"encoding/json"
+	"math/rand"
+)
+
+// samplePayload drops an entry's payload, keeping its message, unless the
+// entry is picked to stay whole at Config.FullPayloadSampleRate. The
+// metadata of a trimmed entry records the payload's size as
+// `omittedBytes`. ERROR entries always stay whole.
+func (s *SlogX) samplePayload(entry *LogEntry) {
+	rate := s.config.FullPayloadSampleRate
+	if rate <= 0 || rate >= 1 || entry.Level == ERROR || rand.Float64() < rate {
+		return
+	}
+
+	var payload []interface{}
+	if s.config.ClassifyArgs {
+		payload = append(append(payload, entry.Data...), entry.Errors...)
+		entry.Data, entry.Errors = nil, nil
+	}
+	if len(entry.Args) > 0 {
+		keep := 0
+		if _, ok := entry.Args[0].(string); ok {
+			keep = 1
+		}
+		if !s.config.ClassifyArgs {
+			payload = append(payload, entry.Args[keep:]...)
+		}
+		entry.Args = entry.Args[:keep]
+	}
+	if len(payload) == 0 {
+		return
+	}
+
+	size := 0
+	if data, err := json.Marshal(payload); err == nil {
+		size = len(data)
+	}
+	entry.Metadata[s.metaKey("omittedBytes")] = size
+	s.trimmed.Add(1)
+}
+
+// TrimmedPayloads reports how many entries lost their payload to
+// Config.FullPayloadSampleRate.
+func TrimmedPayloads() uint64 {
+	return getInstance().trimmed.Load()
+}
@@ -0,0 +1,45 @@
+package slogx
+
+import "testing"
+
+func TestFullPayloadSampleRate(t *testing.T) {
+	read := initCapture(t, Config{FullPayloadSampleRate: 0.2})
+	before := TrimmedPayloads()
+
+	const calls = 2000
+	for i := 0; i < calls; i++ {
+		Info("poll", map[string]interface{}{"i": i, "queue": "jobs"})
+	}
+	Error("poll failed", map[string]interface{}{"queue": "jobs"})
+	Info("no payload")
+
+	entries := read()
+	if len(entries) != calls+2 {
+		t.Fatalf("expected %d entries, got %d", calls+2, len(entries))
+	}
+	full := 0
+	for _, entry := range entries[:calls] {
+		if entry.Args[0] != "poll" {
+			t.Fatalf("expected the message on every entry, got %v", entry.Args)
+		}
+		switch {
+		case len(entry.Args) == 2 && entry.Metadata["omittedBytes"] == nil:
+			full++
+		case len(entry.Args) != 1 || entry.Metadata["omittedBytes"].(float64) < 20:
+			t.Fatalf("expected a trimmed entry with a size hint, got %v %v", entry.Args, entry.Metadata["omittedBytes"])
+		}
+	}
+	if full < calls/10 || full > calls*3/10 {
+		t.Errorf("expected about %d full payloads, got %d", calls/5, full)
+	}
+	if trimmed := TrimmedPayloads() - before; trimmed != uint64(calls-full) {
+		t.Errorf("expected %d trimmed payloads counted, got %d", calls-full, trimmed)
+	}
+
+	if errEntry := entries[calls]; len(errEntry.Args) != 2 {
+		t.Errorf("expected ERROR entries kept whole, got %v", errEntry.Args)
+	}
+	if last := entries[calls+1]; last.Args[0] != "no payload" || last.Metadata["omittedBytes"] != nil {
+		t.Errorf("expected an entry without payload left alone, got %+v", last)
+	}
+}
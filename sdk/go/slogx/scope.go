@@ -0,0 +1,30 @@
+package slogx
+
+import "context"
+
+type levelKey struct{}
+
+// WithLevel returns a context that replaces MinLevel for entries logged
+// with LogCtx and that context, e.g. to see DEBUG entries from a single
+// request while the rest of the app stays at INFO. The override travels
+// with the context rather than the goroutine, so it reaches the goroutines
+// the context is passed to and nothing else. An unknown level leaves ctx
+// unchanged.
+func WithLevel(ctx context.Context, level LogLevel) context.Context {
+	if _, ok := levelRank[level]; !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, levelKey{}, level)
+}
+
+// scopedLevel returns the WithLevel override of the context passed by
+// LogCtx, if any.
+func scopedLevel(args []interface{}) (LogLevel, bool) {
+	for _, arg := range args {
+		if c, ok := arg.(ctxOption); ok && c.ctx != nil {
+			level, ok := c.ctx.Value(levelKey{}).(LogLevel)
+			return level, ok
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,28 @@
+package slogx
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWithLevel_OverridesMinLevelInScope(t *testing.T) {
+	read := initCapture(t, Config{MinLevel: INFO})
+
+	ctx := WithLevel(context.Background(), DEBUG)
+	LogCtx(ctx, DEBUG, "inside")
+	LogCtx(ctx, TRACE, "still too verbose")
+	Debug("outside")
+	LogCtx(context.Background(), DEBUG, "unscoped context")
+
+	entries := read()
+	if len(entries) != 1 || entries[0].Args[0] != "inside" {
+		t.Fatalf("expected only the scoped DEBUG entry, got %+v", entries)
+	}
+
+	quiet := WithLevel(context.Background(), ERROR)
+	LogCtx(quiet, WARN, "silenced")
+	LogCtx(WithLevel(ctx, "LOUD"), DEBUG, "unknown level keeps the scope")
+	if entries := read(); len(entries) != 2 || entries[1].Args[0] != "unknown level keeps the scope" {
+		t.Errorf("expected a raised scope to filter and an unknown level to be ignored, got %+v", entries)
+	}
+}
@@ -1,8 +1,20 @@
 package slogx
 
 import (
+	"bytes"
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"math"
+	"path/filepath"
 	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 	"unsafe"
 )
 
@@ -13,11 +25,147 @@ func Serialize(v interface{}) interface{} {
 	if v == nil {
 		return nil
 	}
-	seen := make(map[uintptr]bool)
-	return serializeValue(reflect.ValueOf(v), seen)
+	ser := newSerializer(&Config{})
+	result := ser.serialize(v)
+	ser.finish()
+	return result
+}
+
+// serializer holds the state shared by every value serialized into one entry.
+type serializer struct {
+	config *Config
+
+	// ancestors holds the containers (pointers, maps, slice backing arrays)
+	// on the path from the root to the current value. Revisiting one means
+	// a cycle; values merely shared between siblings serialize normally.
+	ancestors map[identity]bool
+
+	// RedactPaths and HashPaths bookkeeping: split patterns and the path to
+	// the value currently being serialized.
+	redactPaths [][]string
+	hashPaths   [][]string
+	path        []string
+
+	// DedupRefs bookkeeping: pointer identity -> assigned id, id -> emitted
+	// definition, and which ids were actually referenced.
+	refIDs    map[identity]string
+	refDefs   map[string]map[string]interface{}
+	refsUsed  map[string]bool
+	nextRefID int
+
+	// depth is how many pointers and containers enclose the current value.
+	depth int
+
+	// keyCollisions counts maps whose distinct keys stringified alike.
+	keyCollisions int
+}
+
+func newSerializer(config *Config) *serializer {
+	s := &serializer{
+		config:    config,
+		ancestors: make(map[identity]bool),
+	}
+	for _, p := range config.RedactPaths {
+		s.redactPaths = append(s.redactPaths, strings.Split(p, "."))
+	}
+	for _, p := range config.HashPaths {
+		s.hashPaths = append(s.hashPaths, strings.Split(p, "."))
+	}
+	return s
+}
+
+// identity identifies a container by address and type, so a struct and its
+// first field sharing an address aren't mistaken for each other.
+type identity struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// enter pushes a container onto the ancestor set, reporting false if it is
+// already there.
+func (s *serializer) enter(id identity) bool {
+	if s.ancestors[id] {
+		return false
+	}
+	s.ancestors[id] = true
+	return true
+}
+
+func (s *serializer) leave(id identity) {
+	delete(s.ancestors, id)
+}
+
+func (s *serializer) serialize(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	return s.serializeValue(reflect.ValueOf(v))
+}
+
+// serializeChild serializes a struct field, map value, or slice element found
+// under segment, redacting or hashing it when its path matches a RedactPaths
+// or HashPaths pattern.
+func (s *serializer) serializeChild(segment string, val reflect.Value) interface{} {
+	if !s.tracksPaths() {
+		return s.serializeValue(val)
+	}
+
+	s.path = append(s.path, segment)
+	defer func() { s.path = s.path[:len(s.path)-1] }()
+
+	if s.pathRedacted() {
+		return "[redacted]"
+	}
+	if s.pathMatches(s.hashPaths) {
+		return s.hashValue(val)
+	}
+	return s.serializeValue(val)
+}
+
+// tracksPaths reports whether any path patterns are configured, which is
+// the only time the path to each value needs to be kept.
+func (s *serializer) tracksPaths() bool {
+	return s.redactPaths != nil || s.hashPaths != nil
+}
+
+// pathRedacted reports whether the current path matches a RedactPaths
+// pattern.
+func (s *serializer) pathRedacted() bool {
+	return s.pathMatches(s.redactPaths)
+}
+
+// pathMatches reports whether the current path matches any pattern, where a
+// `*` segment matches exactly one path segment.
+func (s *serializer) pathMatches(patterns [][]string) bool {
+	for _, pattern := range patterns {
+		if len(pattern) != len(s.path) {
+			continue
+		}
+		matched := true
+		for i, seg := range pattern {
+			if seg != "*" && seg != s.path[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
 }
 
-func serializeValue(val reflect.Value, seen map[uintptr]bool) interface{} {
+// finish strips `$id` markers from definitions that were never referenced,
+// so only genuinely shared values carry one.
+func (s *serializer) finish() {
+	for id, def := range s.refDefs {
+		if !s.refsUsed[id] {
+			delete(def, "$id")
+		}
+	}
+}
+
+func (s *serializer) serializeValue(val reflect.Value) interface{} {
 	if !val.IsValid() {
 		return nil
 	}
@@ -27,7 +175,22 @@ func serializeValue(val reflect.Value, seen map[uintptr]bool) interface{} {
 		if val.IsNil() {
 			return nil
 		}
-		return serializeValue(val.Elem(), seen)
+		return s.serializeValue(val.Elem())
+	}
+
+	// Every pointer hop and container counts, so a long linked list is cut
+	// off before it can exhaust the stack.
+	switch val.Kind() {
+	case reflect.Ptr, reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		if s.depth >= s.maxDepth() {
+			return maxDepthMarker
+		}
+		s.depth++
+		defer func() { s.depth-- }()
+	}
+
+	if result, ok := s.serializeSpecial(val); ok {
+		return result
 	}
 
 	// Dereference pointers with cycle detection
@@ -35,63 +198,298 @@ func serializeValue(val reflect.Value, seen map[uintptr]bool) interface{} {
 		if val.IsNil() {
 			return nil
 		}
-		ptr := val.Pointer()
-		if seen[ptr] {
+		if s.config.DedupRefs {
+			return s.serializeRef(val)
+		}
+		id := identity{val.Pointer(), val.Type()}
+		if !s.enter(id) {
 			return "[circular]"
 		}
-		seen[ptr] = true
-		return serializeValue(val.Elem(), seen)
+		defer s.leave(id)
+		return s.serializeValue(val.Elem())
 	}
 
 	switch val.Kind() {
 	case reflect.Struct:
-		return serializeStruct(val, seen)
+		return s.serializeStruct(val)
 
 	case reflect.Map:
-		return serializeMap(val, seen)
+		return s.serializeMap(val)
 
 	case reflect.Slice:
 		if val.IsNil() {
 			return nil
 		}
-		return serializeSlice(val, seen)
+		if s.config.BytesAsString && val.Type().Elem().Kind() == reflect.Uint8 {
+			return s.serializeBytes(val.Bytes())
+		}
+		// Empty slices may share a zero-size backing address.
+		if val.Len() > 0 {
+			id := identity{val.Pointer(), val.Type()}
+			if !s.enter(id) {
+				return "[circular]"
+			}
+			defer s.leave(id)
+		}
+		return s.serializeSlice(val)
 
 	case reflect.Array:
-		return serializeSlice(val, seen)
+		return s.serializeSlice(val)
 
 	case reflect.Chan:
-		return fmt.Sprintf("<chan %s>", val.Type().Elem())
+		if val.IsNil() {
+			return fmt.Sprintf("<nil chan %s>", val.Type().Elem())
+		}
+		return fmt.Sprintf("<chan %s %s len=%d cap=%d>", val.Type().Elem(), chanDirName(val.Type().ChanDir()), val.Len(), val.Cap())
 
 	case reflect.Func:
 		if val.IsNil() {
 			return "<nil func>"
 		}
+		// Closures carry synthetic names like main.run.func1, and method
+		// values a -fm suffix, which is dropped.
+		if fn := runtime.FuncForPC(val.Pointer()); fn != nil {
+			name := strings.TrimSuffix(filepath.Base(fn.Name()), "-fm")
+			return fmt.Sprintf("<func %s %s>", name, val.Type())
+		}
 		return fmt.Sprintf("<func %s>", val.Type())
 
+	case reflect.String:
+		return s.sanitizeString(val.String())
+
 	case reflect.UnsafePointer:
-		return fmt.Sprintf("<unsafe.Pointer %v>", val.Pointer())
+		return fmt.Sprintf("<unsafe.Pointer 0x%x>", val.Pointer())
 
-	default:
-		// Basic types: int, string, bool, float, etc.
+	case reflect.Uintptr:
+		return fmt.Sprintf("<uintptr 0x%x>", val.Uint())
+
+	case reflect.Float32, reflect.Float64:
+		// JSON can't represent NaN or ±Inf, and a single one would make the
+		// whole entry fail to marshal.
+		if f := val.Float(); math.IsNaN(f) {
+			return "NaN"
+		} else if math.IsInf(f, 1) {
+			return "+Inf"
+		} else if math.IsInf(f, -1) {
+			return "-Inf"
+		}
+		if val.CanInterface() {
+			return val.Interface()
+		}
+		return basicValue(val)
+
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if s.config.BigIntsAsStrings {
+			if str, ok := bigIntString(val); ok {
+				return str
+			}
+		}
 		if val.CanInterface() {
 			return val.Interface()
 		}
+		return basicValue(val)
+
+	case reflect.Complex64, reflect.Complex128:
+		// JSON has no complex numbers; use Go's literal form, e.g. "(1+2i)".
+		return strconv.FormatComplex(val.Complex(), 'g', -1, val.Type().Bits())
+
+	default:
+		// Every kind is handled above; this only guards kinds added to
+		// reflect in the future.
+		return fmt.Sprintf("<%s>", val.Type())
+	}
+}
+
+// maxSafeInteger is 2^53-1, the largest integer a JavaScript number holds
+// exactly.
+const maxSafeInteger = 1<<53 - 1
+
+// bigIntString formats an integer beyond JavaScript's safe range as a
+// decimal string for BigIntsAsStrings, since a browser viewer would round
+// it silently. Other values report false.
+func bigIntString(val reflect.Value) (string, bool) {
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n := val.Int(); n > maxSafeInteger || n < -maxSafeInteger {
+			return strconv.FormatInt(n, 10), true
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n := val.Uint(); n > maxSafeInteger {
+			return strconv.FormatUint(n, 10), true
+		}
+	}
+	return "", false
+}
+
+// basicValue reads a basic value that can't be interfaced (reached through
+// an unexported path) without degrading numbers and bools to strings.
+func basicValue(val reflect.Value) interface{} {
+	switch val.Kind() {
+	case reflect.Bool:
+		return val.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return val.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return val.Uint()
+	case reflect.Float32, reflect.Float64:
+		return val.Float()
+	default:
 		return fmt.Sprintf("%v", val)
 	}
 }
 
-func serializeStruct(val reflect.Value, seen map[uintptr]bool) map[string]interface{} {
+// serializeRef emits a pointer to a struct or map once as a full definition
+// tagged with `$id`, and every later occurrence as `{"$ref": id}`. Cycles
+// resolve to a reference back to the enclosing definition.
+func (s *serializer) serializeRef(val reflect.Value) interface{} {
+	elemKind := val.Elem().Kind()
+	if elemKind != reflect.Struct && elemKind != reflect.Map {
+		return s.serializeValue(val.Elem())
+	}
+
+	// Keyed by type too: a pointer to a struct's first field has the
+	// struct's address but refers to something else.
+	ptr := identity{val.Pointer(), val.Type()}
+	if id, ok := s.refIDs[ptr]; ok {
+		s.refsUsed[id] = true
+		return map[string]interface{}{"$ref": id}
+	}
+
+	if s.refIDs == nil {
+		s.refIDs = make(map[identity]string)
+		s.refDefs = make(map[string]map[string]interface{})
+		s.refsUsed = make(map[string]bool)
+	}
+	s.nextRefID++
+	id := strconv.Itoa(s.nextRefID)
+	s.refIDs[ptr] = id
+
+	result := s.serializeValue(val.Elem())
+	switch def := result.(type) {
+	case map[string]interface{}:
+		def["$id"] = id
+		s.refDefs[id] = def
+	case *orderedObject:
+		def.keys = append([]string{"$id"}, def.keys...)
+		def.values["$id"] = id
+		s.refDefs[id] = def.values
+	}
+	return result
+}
+
+// sanitizeString replaces invalid UTF-8 with the replacement rune and, when
+// EscapeControlChars is set, rewrites control characters into visible escapes.
+func (s *serializer) sanitizeString(str string) string {
+	if !utf8.ValidString(str) {
+		str = strings.ToValidUTF8(str, string(utf8.RuneError))
+	}
+	if s.config.EscapeControlChars {
+		str = escapeControlChars(str)
+	}
+	if s.config.MaxStringLen > 0 {
+		str = truncateString(str, s.config.MaxStringLen)
+	}
+	return str
+}
+
+// serializeBytes renders a byte slice for BytesAsString: as text when it is
+// printable UTF-8, otherwise base64-encoded.
+func (s *serializer) serializeBytes(b []byte) string {
+	if printableText(b) {
+		return s.sanitizeString(string(b))
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// printableText reports whether b is valid UTF-8 made only of printable
+// runes, spaces, tabs and line breaks. A single control byte makes the
+// whole slice binary.
+func printableText(b []byte) bool {
+	if !utf8.Valid(b) {
+		return false
+	}
+	for _, r := range string(b) {
+		if r == '\t' || r == '\n' || r == '\r' {
+			continue
+		}
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// truncateString cuts str to at most limit bytes on a rune boundary and
+// notes how many bytes were dropped.
+func truncateString(str string, limit int) string {
+	if len(str) <= limit {
+		return str
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(str[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s…[truncated %d bytes]", str[:cut], len(str)-cut)
+}
+
+func escapeControlChars(str string) string {
+	var b strings.Builder
+	for _, r := range str {
+		switch {
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case unicode.IsControl(r):
+			fmt.Fprintf(&b, `\x%02x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func chanDirName(dir reflect.ChanDir) string {
+	switch dir {
+	case reflect.SendDir:
+		return "send"
+	case reflect.RecvDir:
+		return "recv"
+	default:
+		return "send-recv"
+	}
+}
+
+func (s *serializer) serializeStruct(val reflect.Value) map[string]interface{} {
 	result := make(map[string]interface{})
 	t := val.Type()
 
-	// Make value addressable if it isn't (needed for unexported fields)
+	unexported := s.unexportedAllowed(t)
+
+	// Make value addressable if it isn't (needed for unexported fields).
+	// Map values and keys, and structs held in interfaces, never are. A
+	// struct holding a lock isn't copied, which would copy the lock too;
+	// only its exported fields are logged.
 	if !val.CanAddr() {
-		valCopy := reflect.New(val.Type()).Elem()
-		valCopy.Set(val)
-		val = valCopy
+		if holdsLock(t) {
+			unexported = false
+		} else {
+			valCopy := reflect.New(val.Type()).Elem()
+			valCopy.Set(val)
+			val = valCopy
+		}
 	}
-
+	limit := s.maxObjectKeys()
 	for i := 0; i < val.NumField(); i++ {
+		if len(result) == limit {
+			result[omittedKey] = fmt.Sprintf("[%d more fields]", val.NumField()-i)
+			break
+		}
+
 		field := t.Field(i)
 		fieldVal := val.Field(i)
 
@@ -99,45 +497,313 @@ func serializeStruct(val reflect.Value, seen map[uintptr]bool) map[string]interf
 		if field.Anonymous && !field.IsExported() {
 			continue
 		}
+		if !field.IsExported() && !unexported {
+			continue
+		}
+		if s.config.OmitZero && fieldVal.IsZero() {
+			continue
+		}
 
-		// Access unexported fields via unsafe
+		// Access unexported fields via unsafe. The result reads like an
+		// exported field, so an interface field's dynamic value (even one of
+		// an unexported type) can be dereferenced and interfaced in turn.
 		if !fieldVal.CanInterface() {
 			fieldVal = reflect.NewAt(fieldVal.Type(), unsafe.Pointer(fieldVal.UnsafeAddr())).Elem()
 		}
 
-		result[field.Name] = serializeValue(fieldVal, seen)
+		if fieldHashed(field) {
+			result[field.Name] = s.hashField(field, fieldVal)
+			continue
+		}
+		if out, ok := s.formatField(field, fieldVal); ok {
+			result[field.Name] = out
+			continue
+		}
+		result[field.Name] = s.serializeChild(field.Name, fieldVal)
 	}
 
 	return result
 }
 
-func serializeMap(val reflect.Value, seen map[uintptr]bool) interface{} {
+// unexportedAllowed reports whether unexported fields of t may be logged:
+// always when UnexportedAllowlist is empty, otherwise only for listed types.
+func (s *serializer) unexportedAllowed(t reflect.Type) bool {
+	if len(s.config.UnexportedAllowlist) == 0 {
+		return true
+	}
+	for _, allowed := range s.config.UnexportedAllowlist {
+		if allowed == t {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *serializer) serializeMap(val reflect.Value) interface{} {
 	if val.IsNil() {
 		return nil
 	}
 
 	// Check for cycles in maps
-	ptr := val.Pointer()
-	if seen[ptr] {
+	id := identity{val.Pointer(), val.Type()}
+	if !s.enter(id) {
 		return "[circular]"
 	}
-	seen[ptr] = true
+	defer s.leave(id)
 
-	result := make(map[string]interface{})
-	iter := val.MapRange()
-	for iter.Next() {
-		key := iter.Key()
-		keyStr := fmt.Sprintf("%v", key.Interface())
-		result[keyStr] = serializeValue(iter.Value(), seen)
+	keys := val.MapKeys()
+	names := make([]string, len(keys))
+	seen := make(map[string]bool, len(keys))
+	collided := false
+	for i, key := range keys {
+		names[i] = s.mapKey(key)
+		if seen[names[i]] {
+			collided = true
+		}
+		seen[names[i]] = true
+	}
+
+	if collided {
+		s.keyCollisions++
+	}
+
+	// Numeric keys are written in numeric order, which encoding/json would
+	// lose by sorting them as strings.
+	numeric := isNumberKind(val.Type().Key().Kind())
+	limit := s.maxObjectKeys()
+	if !collided && len(keys) <= limit && !numeric {
+		result := make(map[string]interface{}, len(keys))
+		for i, key := range keys {
+			result[names[i]] = s.serializeChild(names[i], val.MapIndex(key))
+		}
+		return result
+	}
+
+	// Sort so both the kept keys and collision suffixes are stable across
+	// calls.
+	order := sortedKeys(keys, names)
+
+	if collided && s.config.MapEntriesOnCollision {
+		return s.serializeMapEntries(val, keys, order)
+	}
+
+	result := make(map[string]interface{}, len(keys))
+	written := make([]string, 0, len(keys))
+	for n, i := range order {
+		if n == limit {
+			result[omittedKey] = fmt.Sprintf("[%d more keys]", len(keys)-limit)
+			written = append(written, omittedKey)
+			break
+		}
+		name := names[i]
+		// Disambiguate later keys that stringify like an earlier one.
+		for suffix := 2; hasKey(result, name); suffix++ {
+			name = fmt.Sprintf("%s#%d", names[i], suffix)
+		}
+		result[name] = s.serializeChild(name, val.MapIndex(keys[i]))
+		written = append(written, name)
+	}
+	if numeric {
+		return &orderedObject{keys: written, values: result}
 	}
 	return result
 }
 
-func serializeSlice(val reflect.Value, seen map[uintptr]bool) []interface{} {
+func isNumberKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// orderedObject is a JSON object written with its keys in the given order
+// instead of sorted as strings. Keys no longer in values are skipped, so a
+// DedupRefs `$id` can be dropped by deleting it.
+type orderedObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// objectFields returns the keys of a serialized object, plain or ordered,
+// in the order they are written.
+func objectFields(v interface{}) (keys []string, values map[string]interface{}, ok bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys = make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return keys, v, true
+	case *orderedObject:
+		keys = make([]string, 0, len(v.keys))
+		for _, k := range v.keys {
+			if _, ok := v.values[k]; ok {
+				keys = append(keys, k)
+			}
+		}
+		return keys, v.values, true
+	}
+	return nil, nil, false
+}
+
+func (o *orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	// The caller's encoder escapes HTML when compacting this output, if
+	// configured to.
+	enc.SetEscapeHTML(false)
+	buf.WriteByte('{')
+	first := true
+	for _, k := range o.keys {
+		v, ok := o.values[k]
+		if !ok {
+			continue
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		if err := enc.Encode(k); err != nil {
+			return nil, err
+		}
+		buf.Truncate(buf.Len() - 1) // Encode's trailing newline
+		buf.WriteByte(':')
+		if err := enc.Encode(v); err != nil {
+			return nil, err
+		}
+		buf.Truncate(buf.Len() - 1)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func hasKey(m map[string]interface{}, key string) bool {
+	_, ok := m[key]
+	return ok
+}
+
+// sortedKeys returns the indexes of keys in natural order for their kind:
+// numbers by value (so 2 comes before 10), strings and everything else by
+// name. Keys that still tie, e.g. colliding names, are ordered by their Go
+// syntax representation. The order decides which keys survive
+// MaxObjectKeys and how collisions are suffixed, and maps with numeric keys
+// are written in it.
+func sortedKeys(keys []reflect.Value, names []string) []int {
+	order := make([]int, len(keys))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		i, j := order[a], order[b]
+		if c := compareKeys(keys[i], keys[j]); c != 0 {
+			return c < 0
+		}
+		if names[i] != names[j] {
+			return names[i] < names[j]
+		}
+		return fmt.Sprintf("%#v", keys[i].Interface()) < fmt.Sprintf("%#v", keys[j].Interface())
+	})
+	return order
+}
+
+// compareKeys orders two map keys of the same numeric, string or bool kind
+// by value, returning 0 when they are equal or not comparable that way.
+func compareKeys(a, b reflect.Value) int {
+	if a.Kind() == reflect.Interface {
+		a, b = a.Elem(), b.Elem()
+	}
+	if !a.IsValid() || !b.IsValid() || a.Kind() != b.Kind() {
+		return 0
+	}
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(a.Int(), b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return cmp.Compare(a.Uint(), b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(a.Float(), b.Float())
+	case reflect.String:
+		return cmp.Compare(a.String(), b.String())
+	case reflect.Bool:
+		return cmp.Compare(boolRank(a.Bool()), boolRank(b.Bool()))
+	}
+	return 0
+}
+
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// serializeMapEntries represents a map as `[{"key": k, "value": v}]`, so
+// keys that stringify alike stay distinct and keep their structure. The
+// entries are in sortedKeys order.
+func (s *serializer) serializeMapEntries(val reflect.Value, keys []reflect.Value, order []int) []interface{} {
+	entries := make([]interface{}, 0, len(keys))
+	for n, i := range order {
+		if n == s.maxObjectKeys() {
+			entries = append(entries, map[string]interface{}{omittedKey: fmt.Sprintf("[%d more keys]", len(keys)-n)})
+			break
+		}
+		entries = append(entries, map[string]interface{}{
+			"key":   s.serializeValue(keys[i]),
+			"value": s.serializeChild(strconv.Itoa(n), val.MapIndex(keys[i])),
+		})
+	}
+	return entries
+}
+
+// maxDepthMarker replaces values nested deeper than MaxDepth.
+const maxDepthMarker = "[max depth exceeded]"
+
+const defaultMaxDepth = 128
+
+func (s *serializer) maxDepth() int {
+	if s.config.MaxDepth > 0 {
+		return s.config.MaxDepth
+	}
+	return defaultMaxDepth
+}
+
+// omittedKey holds the summary of keys dropped by MaxObjectKeys.
+const omittedKey = "…"
+
+const (
+	defaultMaxObjectKeys = 1000
+	defaultMaxKeyLen     = 256
+)
+
+func (s *serializer) maxObjectKeys() int {
+	if s.config.MaxObjectKeys > 0 {
+		return s.config.MaxObjectKeys
+	}
+	return defaultMaxObjectKeys
+}
+
+// mapKey stringifies a map key, capping its length at MaxKeyLen.
+func (s *serializer) mapKey(key reflect.Value) string {
+	limit := s.config.MaxKeyLen
+	if limit <= 0 {
+		limit = defaultMaxKeyLen
+	}
+	return truncateString(s.sanitizeString(fmt.Sprintf("%v", key.Interface())), limit)
+}
+
+func (s *serializer) serializeSlice(val reflect.Value) []interface{} {
 	length := val.Len()
 	result := make([]interface{}, length)
 	for i := 0; i < length; i++ {
-		result[i] = serializeValue(val.Index(i), seen)
+		if s.tracksPaths() {
+			result[i] = s.serializeChild(strconv.Itoa(i), val.Index(i))
+		} else {
+			result[i] = s.serializeValue(val.Index(i))
+		}
 	}
 	return result
 }
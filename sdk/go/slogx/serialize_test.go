@@ -1,7 +1,15 @@
 package slogx
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+	"unsafe"
 )
 
 // --- Test structs ---
@@ -130,6 +138,108 @@ func TestSerialize_UnexportedFields(t *testing.T) {
 	}
 }
 
+func TestSerialize_MapOfStructWithUnexportedFields(t *testing.T) {
+	input := map[string]mixedStruct{
+		"a": {Public: "one", private: "secret-a", Count: 1, hidden: true},
+		"b": {Public: "two", private: "secret-b", Count: 2},
+	}
+	m, ok := Serialize(input).(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected map, got %T", Serialize(input))
+	}
+	a, ok := m["a"].(map[string]interface{})
+	if !ok || a["private"] != "secret-a" || a["hidden"] != true || a["Count"] != 1 {
+		t.Errorf("expected unexported fields of a map value, got %v", m["a"])
+	}
+
+	// Structs held in interface map values aren't addressable either.
+	boxed := map[string]interface{}{"b": input["b"]}
+	m = Serialize(boxed).(map[string]interface{})
+	if b := m["b"].(map[string]interface{}); b["private"] != "secret-b" {
+		t.Errorf("expected unexported fields of an interface map value, got %v", b)
+	}
+}
+
+type auditSink interface{ Write(event string) }
+
+type fileAuditSink struct {
+	path    string
+	written int
+}
+
+func (*fileAuditSink) Write(string) {}
+
+type auditedService struct {
+	Name     string
+	sink     auditSink
+	fallback auditSink
+	extra    interface{}
+	started  interface{}
+}
+
+func TestSerialize_UnexportedInterfaceFields(t *testing.T) {
+	at := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	svc := auditedService{
+		Name:    "billing",
+		sink:    &fileAuditSink{path: "/var/log/audit", written: 3},
+		extra:   fileAuditSink{path: "/tmp/audit"},
+		started: at,
+	}
+	expected := map[string]interface{}{
+		"Name":     "billing",
+		"sink":     map[string]interface{}{"path": "/var/log/audit", "written": 3},
+		"fallback": nil,
+		"extra":    map[string]interface{}{"path": "/tmp/audit", "written": 0},
+		"started":  "2024-03-01T09:30:00Z",
+	}
+	if result := Serialize(svc); !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected interface fields dereferenced, got %#v", result)
+	}
+
+	// Held in a map, the struct isn't addressable and is copied first.
+	byName := map[string]auditedService{"billing": svc, "empty": {}}
+	result := Serialize(byName).(map[string]interface{})
+	if !reflect.DeepEqual(result["billing"], expected) {
+		t.Errorf("expected the same result from a map value, got %#v", result["billing"])
+	}
+	empty := result["empty"].(map[string]interface{})
+	for _, field := range []string{"sink", "fallback", "extra", "started"} {
+		if v, ok := empty[field]; !ok || v != nil {
+			t.Errorf("expected nil interface %s as null, got %v", field, v)
+		}
+	}
+}
+
+func TestSerialize_MapOfPointers(t *testing.T) {
+	shared := &mixedStruct{Public: "shared", private: "s", Count: 2}
+	input := map[string]*mixedStruct{
+		"missing": nil,
+		"unique":  {Public: "unique", Count: 1},
+		"first":   shared,
+		"second":  shared,
+	}
+	// Also shared across sibling maps, one level down.
+	nested := map[string]map[string]*mixedStruct{"a": input, "b": {"again": shared}}
+
+	full := map[string]interface{}{"Public": "shared", "private": "s", "Count": 2, "hidden": false}
+	result := Serialize(nested).(map[string]interface{})
+	a := result["a"].(map[string]interface{})
+	if v, ok := a["missing"]; !ok || v != nil {
+		t.Errorf("expected a nil pointer value as null, got %v", v)
+	}
+	if unique := a["unique"].(map[string]interface{}); unique["Public"] != "unique" {
+		t.Errorf("expected the unique value in full, got %v", unique)
+	}
+	for _, v := range []interface{}{a["first"], a["second"], result["b"].(map[string]interface{})["again"]} {
+		if !reflect.DeepEqual(v, full) {
+			t.Errorf("expected every occurrence of the shared value in full, got %v", v)
+		}
+	}
+	if n := countCircular(result); n != 0 {
+		t.Errorf("expected no [circular] markers for shared values, got %d", n)
+	}
+}
+
 func TestSerialize_NestedStruct(t *testing.T) {
 	inner := &mixedStruct{Public: "inner", private: "secret", Count: 10, hidden: false}
 	input := nestedStruct{ID: 1, Inner: inner}
@@ -220,8 +330,48 @@ func TestSerialize_Channel(t *testing.T) {
 	if !ok {
 		t.Fatalf("expected Ch to be string, got %T", m["Ch"])
 	}
-	if chStr != "<chan int>" {
-		t.Errorf("expected Ch=<chan int>, got %v", chStr)
+	if chStr != "<chan int send-recv len=0 cap=0>" {
+		t.Errorf("expected Ch=<chan int send-recv len=0 cap=0>, got %v", chStr)
+	}
+}
+
+func TestSerialize_BufferedChannel(t *testing.T) {
+	ch := make(chan int, 10)
+	ch <- 1
+	ch <- 2
+
+	result := Serialize(ch)
+	if result != "<chan int send-recv len=2 cap=10>" {
+		t.Errorf("expected <chan int send-recv len=2 cap=10>, got %v", result)
+	}
+}
+
+func TestSerialize_DirectionalChannel(t *testing.T) {
+	ch := make(chan string, 3)
+	ch <- "queued"
+
+	var recv <-chan string = ch
+	if result := Serialize(recv); result != "<chan string recv len=1 cap=3>" {
+		t.Errorf("expected <chan string recv len=1 cap=3>, got %v", result)
+	}
+
+	var send chan<- string = ch
+	if result := Serialize(send); result != "<chan string send len=1 cap=3>" {
+		t.Errorf("expected <chan string send len=1 cap=3>, got %v", result)
+	}
+}
+
+func TestSerialize_NilChannel(t *testing.T) {
+	input := withChan{Ch: nil, Name: "test"}
+	result := Serialize(input)
+
+	m, ok := result.(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected map, got %T", result)
+	}
+
+	if m["Ch"] != "<nil chan int>" {
+		t.Errorf("expected Ch=<nil chan int>, got %v", m["Ch"])
 	}
 }
 
@@ -239,8 +389,29 @@ func TestSerialize_Func(t *testing.T) {
 	if !ok {
 		t.Fatalf("expected Fn to be string, got %T", m["Fn"])
 	}
-	if fnStr != "<func func() error>" {
-		t.Errorf("expected Fn=<func func() error>, got %v", fnStr)
+	if fnStr != "<func slogx.TestSerialize_Func.func1 func() error>" {
+		t.Errorf("expected the closure's name and type, got %v", fnStr)
+	}
+}
+
+type retryPolicyFunc struct{ attempts int }
+
+func (p *retryPolicyFunc) Next(err error) bool { return p.attempts > 0 }
+
+func TestSerialize_FuncNames(t *testing.T) {
+	policy := &retryPolicyFunc{attempts: 3}
+	input := map[string]interface{}{
+		"topLevel": Serialize,
+		"method":   policy.Next,
+		"builtin":  strings.ToUpper,
+	}
+	expected := map[string]interface{}{
+		"topLevel": "<func slogx.Serialize func(interface {}) interface {}>",
+		"method":   "<func slogx.(*retryPolicyFunc).Next func(error) bool>",
+		"builtin":  "<func strings.ToUpper func(string) string>",
+	}
+	if result := Serialize(input); !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected function names, got %v", result)
 	}
 }
 
@@ -397,3 +568,976 @@ func TestSerialize_CircularMap(t *testing.T) {
 		t.Errorf("expected self=[circular], got %v", rm["self"])
 	}
 }
+
+type sharedSessionUser struct {
+	Name    string
+	Session *mixedStruct
+}
+
+func TestSerialize_DedupRefs_SharedPointer(t *testing.T) {
+	session := &mixedStruct{Public: "session", private: "token", Count: 1}
+	users := []*sharedSessionUser{
+		{Name: "alice", Session: session},
+		{Name: "bob", Session: session},
+	}
+
+	ser := newSerializer(&Config{DedupRefs: true})
+	result := ser.serialize(users)
+	ser.finish()
+
+	items, ok := result.([]interface{})
+	if !ok || len(items) != 2 {
+		t.Fatalf("expected 2 items, got %v", result)
+	}
+
+	first := items[0].(map[string]interface{})
+	second := items[1].(map[string]interface{})
+
+	// Unshared pointers carry no $id
+	if _, ok := first["$id"]; ok {
+		t.Errorf("expected unshared user to have no $id, got %v", first)
+	}
+
+	def, ok := first["Session"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected full session definition, got %v", first["Session"])
+	}
+	if def["private"] != "token" {
+		t.Errorf("expected full definition fields, got %v", def)
+	}
+
+	ref, ok := second["Session"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected session reference, got %v", second["Session"])
+	}
+	if len(ref) != 1 || ref["$ref"] != def["$id"] {
+		t.Errorf("expected {$ref: %v}, got %v", def["$id"], ref)
+	}
+}
+
+func TestSerialize_DedupRefs_Cycle(t *testing.T) {
+	input := &circularStruct{Name: "root"}
+	input.Self = input
+
+	ser := newSerializer(&Config{DedupRefs: true})
+	result := ser.serialize(input)
+	ser.finish()
+
+	m, ok := result.(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected map, got %T", result)
+	}
+
+	self, ok := m["Self"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected Self to be a reference, got %v", m["Self"])
+	}
+	if self["$ref"] != m["$id"] {
+		t.Errorf("expected Self to reference root %v, got %v", m["$id"], self)
+	}
+}
+
+type refInner struct{ X int }
+
+type refOuter struct {
+	Inner refInner
+	Name  string
+}
+
+func TestSerialize_DedupRefs_FirstFieldPointer(t *testing.T) {
+	o := &refOuter{Inner: refInner{X: 1}, Name: "o"}
+
+	ser := newSerializer(&Config{DedupRefs: true})
+	outer := ser.serialize(o).(map[string]interface{})
+	inner := ser.serialize(&o.Inner)
+	ser.finish()
+
+	if _, ok := outer["$id"]; ok {
+		t.Errorf("expected the outer struct to stay unreferenced, got %v", outer)
+	}
+	if m, ok := inner.(map[string]interface{}); !ok || m["X"] != 1 || m["$ref"] != nil {
+		t.Errorf("expected the inner struct in full, not a ref to its parent, got %v", inner)
+	}
+}
+
+func TestSerialize_DedupRefs_Disabled(t *testing.T) {
+	session := &mixedStruct{Public: "session"}
+	users := []*sharedSessionUser{
+		{Name: "alice", Session: session},
+		{Name: "bob", Session: session},
+	}
+
+	result := Serialize(users).([]interface{})
+	second := result[1].(map[string]interface{})
+	full, ok := second["Session"].(map[string]interface{})
+	if !ok || full["Public"] != "session" {
+		t.Errorf("expected shared pointer to be serialized in full, got %v", second["Session"])
+	}
+	if _, ok := full["$id"]; ok {
+		t.Errorf("expected no reference markers by default, got %v", full)
+	}
+}
+
+func TestSerialize_InvalidUTF8(t *testing.T) {
+	input := map[string]interface{}{"raw": string([]byte{'o', 'k', 0xff, 0xfe, '!'})}
+	result := Serialize(input).(map[string]interface{})
+
+	if result["raw"] != "ok�!" {
+		t.Errorf("expected invalid bytes replaced, got %q", result["raw"])
+	}
+}
+
+func TestSerialize_EscapeControlChars(t *testing.T) {
+	input := "line1\nline2\ttabbed\x00\x07\r"
+
+	if result := Serialize(input); result != input {
+		t.Errorf("expected control chars untouched by default, got %q", result)
+	}
+
+	ser := newSerializer(&Config{EscapeControlChars: true})
+	result := ser.serialize(input)
+	expected := `line1\nline2\ttabbed\x00\x07\r`
+	if result != expected {
+		t.Errorf("expected %q, got %q", expected, result)
+	}
+}
+
+type credentials struct {
+	Username string
+	password string
+}
+
+func TestSerialize_RedactPaths_Exact(t *testing.T) {
+	ser := newSerializer(&Config{RedactPaths: []string{"user.apiToken"}})
+	input := map[string]interface{}{
+		"user":     map[string]interface{}{"name": "alice", "apiToken": "tok_abc"},
+		"apiToken": "top-level",
+	}
+	result := ser.serialize(input).(map[string]interface{})
+
+	user := result["user"].(map[string]interface{})
+	if user["apiToken"] != "[redacted]" {
+		t.Errorf("expected user.apiToken redacted, got %v", user["apiToken"])
+	}
+	if user["name"] != "alice" {
+		t.Errorf("expected sibling untouched, got %v", user["name"])
+	}
+	if result["apiToken"] != "top-level" {
+		t.Errorf("expected non-matching path untouched, got %v", result["apiToken"])
+	}
+}
+
+func TestSerialize_RedactPaths_Wildcard(t *testing.T) {
+	ser := newSerializer(&Config{RedactPaths: []string{"*.password"}})
+	input := map[string]interface{}{
+		"db":    credentials{Username: "admin", password: "hunter2"},
+		"cache": credentials{Username: "redis", password: "s3cret"},
+	}
+	result := ser.serialize(input).(map[string]interface{})
+
+	for _, key := range []string{"db", "cache"} {
+		creds := result[key].(map[string]interface{})
+		if creds["password"] != "[redacted]" {
+			t.Errorf("expected %s.password redacted, got %v", key, creds["password"])
+		}
+		if creds["Username"] == "[redacted]" {
+			t.Errorf("expected %s.Username untouched", key)
+		}
+	}
+}
+
+func TestSerialize_RedactPaths_DeepNested(t *testing.T) {
+	ser := newSerializer(&Config{RedactPaths: []string{"services.*.auth.token"}})
+	input := map[string]interface{}{
+		"services": []interface{}{
+			map[string]interface{}{"name": "api", "auth": map[string]interface{}{"token": "t1", "kind": "bearer"}},
+			map[string]interface{}{"name": "web", "auth": map[string]interface{}{"token": "t2", "kind": "basic"}},
+		},
+	}
+	result := ser.serialize(input).(map[string]interface{})
+
+	for i, svc := range result["services"].([]interface{}) {
+		auth := svc.(map[string]interface{})["auth"].(map[string]interface{})
+		if auth["token"] != "[redacted]" {
+			t.Errorf("expected services.%d.auth.token redacted, got %v", i, auth["token"])
+		}
+		if auth["kind"] == "[redacted]" {
+			t.Errorf("expected services.%d.auth.kind untouched", i)
+		}
+	}
+}
+
+type apiClient struct {
+	Name   string
+	APIKey string  `slogx:"hash"`
+	Secret string  `slogx:"hash"`
+	Spare  *string `slogx:"hash"`
+}
+
+func TestSerialize_HashedValues(t *testing.T) {
+	ser := newSerializer(&Config{HashPaths: []string{"*.email"}})
+	input := map[string]interface{}{
+		"billing": apiClient{Name: "billing", APIKey: "sk_live_1", Secret: "sk_live_1"},
+		"search":  apiClient{Name: "search", APIKey: "sk_live_2"},
+		"owner":   map[string]interface{}{"email": "ann@example.com"},
+		"auditor": map[string]interface{}{"email": "ann@example.com"},
+	}
+	result := ser.serialize(input).(map[string]interface{})
+
+	billing := result["billing"].(map[string]interface{})
+	search := result["search"].(map[string]interface{})
+	if !strings.HasPrefix(billing["APIKey"].(string), "[hash:") {
+		t.Fatalf("expected a hash, got %v", billing["APIKey"])
+	}
+	if billing["APIKey"] != billing["Secret"] {
+		t.Errorf("expected equal values to hash alike, got %v and %v", billing["APIKey"], billing["Secret"])
+	}
+	if billing["APIKey"] == search["APIKey"] {
+		t.Errorf("expected different values to hash apart, got %v twice", billing["APIKey"])
+	}
+	if billing["Spare"] != nil || billing["Name"] != "billing" {
+		t.Errorf("expected nil and untagged fields left alone, got %v", billing)
+	}
+
+	owner := result["owner"].(map[string]interface{})["email"]
+	if owner != result["auditor"].(map[string]interface{})["email"] || owner == billing["APIKey"] {
+		t.Errorf("expected HashPaths to hash like the tag, got %v", owner)
+	}
+
+	out, _ := json.Marshal(result)
+	for _, raw := range []string{"sk_live_1", "sk_live_2", "ann@example.com"} {
+		if strings.Contains(string(out), raw) {
+			t.Errorf("expected %q never to appear, got %s", raw, out)
+		}
+	}
+
+	ser = newSerializer(&Config{RedactPaths: []string{"APIKey"}})
+	if result := ser.serialize(apiClient{APIKey: "sk_live_1"}).(map[string]interface{}); result["APIKey"] != "[redacted]" {
+		t.Errorf("expected redaction to win over hashing, got %v", result["APIKey"])
+	}
+}
+
+type cycleNode struct {
+	Name     string
+	Children map[string]interface{}
+}
+
+// countCircular counts "[circular]" markers anywhere in a serialized value.
+func countCircular(v interface{}) int {
+	switch v := v.(type) {
+	case string:
+		if v == "[circular]" {
+			return 1
+		}
+	case map[string]interface{}:
+		n := 0
+		for _, child := range v {
+			n += countCircular(child)
+		}
+		return n
+	case []interface{}:
+		n := 0
+		for _, child := range v {
+			n += countCircular(child)
+		}
+		return n
+	}
+	return 0
+}
+
+func TestSerialize_CycleAcrossContainers(t *testing.T) {
+	root := &cycleNode{Name: "root"}
+	root.Children = map[string]interface{}{
+		"list": []interface{}{"sibling", root},
+	}
+
+	result := Serialize(root)
+
+	if n := countCircular(result); n != 1 {
+		t.Fatalf("expected exactly one [circular] marker, got %d in %v", n, result)
+	}
+
+	list := result.(map[string]interface{})["Children"].(map[string]interface{})["list"].([]interface{})
+	if list[0] != "sibling" || list[1] != "[circular]" {
+		t.Errorf("expected the cycle to close at list[1], got %v", list)
+	}
+}
+
+func TestSerialize_CircularSlice(t *testing.T) {
+	s := []interface{}{"a", nil}
+	s[1] = s
+
+	result := Serialize(s).([]interface{})
+	if result[0] != "a" || result[1] != "[circular]" {
+		t.Errorf("expected [a, [circular]], got %v", result)
+	}
+}
+
+func TestSerialize_SharedMapNotCircular(t *testing.T) {
+	shared := map[string]int{"n": 1}
+	result := Serialize([]interface{}{shared, shared}).([]interface{})
+
+	if countCircular(result) != 0 {
+		t.Errorf("expected sibling maps not to be reported as circular, got %v", result)
+	}
+}
+
+func TestSerialize_MaxStringLen(t *testing.T) {
+	ser := newSerializer(&Config{MaxStringLen: 5})
+
+	if result := ser.serialize("short"); result != "short" {
+		t.Errorf("expected short string untouched, got %v", result)
+	}
+	if result := ser.serialize("héllo world"); result != "héll…[truncated 7 bytes]" {
+		t.Errorf("expected truncation on a rune boundary, got %v", result)
+	}
+}
+
+func TestSerialize_BigIntsAsStrings(t *testing.T) {
+	ser := newSerializer(&Config{BigIntsAsStrings: true})
+
+	cases := []struct {
+		in       interface{}
+		expected interface{}
+	}{
+		{int64(1) << 60, "1152921504606846976"},
+		{int64(-1) << 60, "-1152921504606846976"},
+		{uint64(math.MaxUint64), "18446744073709551615"},
+		{42, 42},
+		{int64(1<<53 - 1), int64(1<<53 - 1)},
+		{uint8(255), uint8(255)},
+	}
+	for _, c := range cases {
+		if result := ser.serialize(c.in); result != c.expected {
+			t.Errorf("expected %v to serialize as %#v, got %#v", c.in, c.expected, result)
+		}
+	}
+
+	if result := newSerializer(&Config{}).serialize(int64(1) << 60); result != int64(1)<<60 {
+		t.Errorf("expected big ints to stay numbers by default, got %#v", result)
+	}
+}
+
+func TestSerialize_BytesAsString(t *testing.T) {
+	ser := newSerializer(&Config{BytesAsString: true, MaxStringLen: 16})
+
+	text := []byte("POST /login\n\tuser=ann")
+	if result := ser.serialize(text); result != "POST /login\n\tuse…[truncated 5 bytes]" {
+		t.Errorf("expected text bytes as a truncated string, got %v", result)
+	}
+
+	binary := []byte{0x89, 'P', 'N', 'G', 0x0d, 0x0a, 0x1a, 0x0a}
+	if result := ser.serialize(binary); result != base64.StdEncoding.EncodeToString(binary) {
+		t.Errorf("expected binary bytes base64-encoded, got %v", result)
+	}
+
+	// Otherwise printable text with one control byte is treated as binary.
+	mixed := []byte("status=ok\x00")
+	if result := ser.serialize(mixed); result != base64.StdEncoding.EncodeToString(mixed) {
+		t.Errorf("expected a single control byte to force base64, got %v", result)
+	}
+
+	ser = newSerializer(&Config{})
+	if result := ser.serialize([]byte("ok")); !reflect.DeepEqual(result, []interface{}{uint8('o'), uint8('k')}) {
+		t.Errorf("expected byte arrays without BytesAsString, got %#v", result)
+	}
+}
+
+type sensorReading struct {
+	Ratio    float64
+	Peak     float32
+	variance float64
+}
+
+func TestSerialize_NonFiniteFloats(t *testing.T) {
+	reading := sensorReading{Ratio: math.NaN(), Peak: float32(math.Inf(1)), variance: math.Inf(-1)}
+	expected := map[string]interface{}{"Ratio": "NaN", "Peak": "+Inf", "variance": "-Inf"}
+	if result := Serialize(reading); !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected sentinels in struct fields, got %v", result)
+	}
+
+	values := map[string]interface{}{
+		"nan":    math.NaN(),
+		"posInf": math.Inf(1),
+		"negInf": math.Inf(-1),
+		"series": []float64{1.5, math.NaN(), math.Inf(-1)},
+	}
+	result := Serialize(values)
+	expected = map[string]interface{}{
+		"nan":    "NaN",
+		"posInf": "+Inf",
+		"negInf": "-Inf",
+		"series": []interface{}{1.5, "NaN", "-Inf"},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected sentinels in map values and slice elements, got %v", result)
+	}
+	if _, err := json.Marshal(result); err != nil {
+		t.Errorf("expected the result to marshal, got %v", err)
+	}
+}
+
+type withRawPointers struct {
+	Unsafe unsafe.Pointer
+	Addr   uintptr
+	Nil    unsafe.Pointer
+}
+
+func TestSerialize_PointerishAsHex(t *testing.T) {
+	x := 42
+	input := withRawPointers{Unsafe: unsafe.Pointer(&x), Addr: 0xdeadbeef}
+	result := Serialize(input).(map[string]interface{})
+
+	expectedUnsafe := fmt.Sprintf("<unsafe.Pointer 0x%x>", uintptr(unsafe.Pointer(&x)))
+	if result["Unsafe"] != expectedUnsafe {
+		t.Errorf("expected %s, got %v", expectedUnsafe, result["Unsafe"])
+	}
+	if result["Addr"] != "<uintptr 0xdeadbeef>" {
+		t.Errorf("expected <uintptr 0xdeadbeef>, got %v", result["Addr"])
+	}
+	if result["Nil"] != "<unsafe.Pointer 0x0>" {
+		t.Errorf("expected <unsafe.Pointer 0x0>, got %v", result["Nil"])
+	}
+
+	if _, err := json.Marshal(result); err != nil {
+		t.Errorf("expected result to marshal, got %v", err)
+	}
+}
+
+type holdsMap struct {
+	values map[string]interface{}
+}
+
+func TestSerialize_InterfaceValuesInContainers(t *testing.T) {
+	structVal := mixedStruct{Public: "s", private: "p", Count: 1, hidden: true}
+	ptrVal := &mixedStruct{Public: "ptr", private: "pp", Count: 2}
+	var nilPtr *mixedStruct
+	var nilIface error
+
+	cases := map[string]interface{}{
+		"struct":   structVal,
+		"pointer":  ptrVal,
+		"nil":      nil,
+		"nilPtr":   nilPtr,
+		"nilIface": nilIface,
+		"nested":   nestedStruct{ID: 3, Inner: ptrVal},
+		"slice":    []interface{}{structVal, nil},
+	}
+
+	fromMap := Serialize(cases).(map[string]interface{})
+	fromSlice := Serialize([]interface{}{cases["struct"], cases["pointer"], cases["nil"]}).([]interface{})
+	fromUnexported := Serialize(holdsMap{values: cases}).(map[string]interface{})["values"].(map[string]interface{})
+
+	for key, value := range cases {
+		direct := Serialize(value)
+		if !reflect.DeepEqual(fromMap[key], direct) {
+			t.Errorf("%s: map value %v differs from direct %v", key, fromMap[key], direct)
+		}
+		if !reflect.DeepEqual(fromUnexported[key], direct) {
+			t.Errorf("%s: unexported map value %v differs from direct %v", key, fromUnexported[key], direct)
+		}
+	}
+
+	for i, key := range []string{"struct", "pointer", "nil"} {
+		if direct := Serialize(cases[key]); !reflect.DeepEqual(fromSlice[i], direct) {
+			t.Errorf("%s: slice element %v differs from direct %v", key, fromSlice[i], direct)
+		}
+	}
+}
+
+func TestSerialize_NonInterfaceableBasicValues(t *testing.T) {
+	// Fields read off a non-addressable struct value can't be interfaced.
+	v := reflect.ValueOf(mixedStruct{Count: 7, hidden: true})
+	ser := newSerializer(&Config{})
+
+	if result := ser.serializeValue(v.FieldByName("hidden")); result != true {
+		t.Errorf("expected bool true, got %#v", result)
+	}
+
+	type private struct{ n int }
+	n := reflect.ValueOf(private{n: 5}).Field(0)
+	if result := ser.serializeValue(n); result != int64(5) {
+		t.Errorf("expected int64 5, got %#v", result)
+	}
+}
+
+func TestSerialize_MaxObjectKeys(t *testing.T) {
+	input := make(map[string]int)
+	for i := 0; i < 50; i++ {
+		input[fmt.Sprintf("key%02d", i)] = i
+	}
+
+	ser := newSerializer(&Config{MaxObjectKeys: 10})
+	result := ser.serialize(input).(map[string]interface{})
+
+	if len(result) != 11 {
+		t.Fatalf("expected 10 keys plus a summary, got %d", len(result))
+	}
+	if result["key00"] != 0 || result["key09"] != 9 {
+		t.Errorf("expected the first keys in sorted order, got %v", result)
+	}
+	if result[omittedKey] != "[40 more keys]" {
+		t.Errorf("expected summary of omitted keys, got %v", result[omittedKey])
+	}
+
+	small := ser.serialize(map[string]int{"a": 1}).(map[string]interface{})
+	if _, ok := small[omittedKey]; ok {
+		t.Error("expected maps under the cap to be untouched")
+	}
+}
+
+func TestSortedKeys_NaturalOrder(t *testing.T) {
+	keyNames := func(m interface{}) []string {
+		val := reflect.ValueOf(m)
+		keys := val.MapKeys()
+		names := make([]string, len(keys))
+		for i, key := range keys {
+			names[i] = fmt.Sprint(key.Interface())
+		}
+		ordered := make([]string, len(keys))
+		for n, i := range sortedKeys(keys, names) {
+			ordered[n] = names[i]
+		}
+		return ordered
+	}
+
+	ints := map[int]string{10: "j", 2: "b", -1: "z", 1: "a", 33: "x"}
+	if got := keyNames(ints); !reflect.DeepEqual(got, []string{"-1", "1", "2", "10", "33"}) {
+		t.Errorf("expected int keys in numeric order, got %v", got)
+	}
+	floats := map[float64]bool{2.5: true, 10: true, 0.1: true}
+	if got := keyNames(floats); !reflect.DeepEqual(got, []string{"0.1", "2.5", "10"}) {
+		t.Errorf("expected float keys in numeric order, got %v", got)
+	}
+	strs := map[string]string{"10": "", "2": "", "b": "", "a": ""}
+	if got := keyNames(strs); !reflect.DeepEqual(got, []string{"10", "2", "a", "b"}) {
+		t.Errorf("expected string keys in lexicographic order, got %v", got)
+	}
+}
+
+func TestSerialize_MaxObjectKeys_NumericKeys(t *testing.T) {
+	ser := newSerializer(&Config{MaxObjectKeys: 3})
+	input := make(map[int]string)
+	for i := 1; i <= 12; i++ {
+		input[i] = fmt.Sprint("v", i)
+	}
+
+	out, _ := json.Marshal(ser.serialize(input))
+	if want := `{"1":"v1","2":"v2","3":"v3","…":"[9 more keys]"}`; string(out) != want {
+		t.Errorf("expected the smallest keys kept, got %s", out)
+	}
+}
+
+func TestSerialize_MapKeyOrder(t *testing.T) {
+	out, _ := json.Marshal(Serialize(map[int]string{2: "b", 10: "j", 1: "a", -3: "m"}))
+	if want := `{"-3":"m","1":"a","2":"b","10":"j"}`; string(out) != want {
+		t.Errorf("expected int keys in numeric order: want %s, got %s", want, out)
+	}
+
+	out, _ = json.Marshal(Serialize(map[float64]int{2.5: 1, 10: 2, 0.1: 3}))
+	if want := `{"0.1":3,"2.5":1,"10":2}`; string(out) != want {
+		t.Errorf("expected float keys in numeric order: want %s, got %s", want, out)
+	}
+
+	out, _ = json.Marshal(Serialize(map[string]string{"2": "b", "10": "j", "a": "x", "1": "a"}))
+	if want := `{"1":"a","10":"j","2":"b","a":"x"}`; string(out) != want {
+		t.Errorf("expected string keys in lexicographic order: want %s, got %s", want, out)
+	}
+
+	// Nested numeric maps keep their order, and values aren't HTML-escaped
+	// twice when the outer encoder escapes.
+	out, _ = json.Marshal(Serialize(map[string]interface{}{"byID": map[uint]string{20: "<b>", 3: "c"}}))
+	if want := `{"byID":{"3":"c","20":"\u003cb\u003e"}}`; string(out) != want {
+		t.Errorf("expected nested numeric keys in order: want %s, got %s", want, out)
+	}
+}
+
+func TestSerialize_MaxObjectKeys_Struct(t *testing.T) {
+	ser := newSerializer(&Config{MaxObjectKeys: 2})
+	result := ser.serialize(mixedStruct{Public: "p", private: "x", Count: 1}).(map[string]interface{})
+
+	if result["Public"] != "p" || result["private"] != "x" {
+		t.Errorf("expected leading fields kept, got %v", result)
+	}
+	if result[omittedKey] != "[2 more fields]" {
+		t.Errorf("expected field summary, got %v", result[omittedKey])
+	}
+}
+
+func TestSerialize_MaxKeyLen(t *testing.T) {
+	longKey := strings.Repeat("k", 1000)
+	ser := newSerializer(&Config{MaxKeyLen: 16})
+	result := ser.serialize(map[string]int{longKey: 1, "short": 2}).(map[string]interface{})
+
+	if result["short"] != 2 {
+		t.Errorf("expected short key untouched, got %v", result)
+	}
+	truncated := strings.Repeat("k", 16) + "…[truncated 984 bytes]"
+	if result[truncated] != 1 {
+		t.Errorf("expected long key truncated to %q, got %v", truncated, result)
+	}
+}
+
+// pointKey stringifies via %v the same for {"a b", "c"} and {"a", "b c"}.
+type pointKey struct {
+	Label string
+	Tag   string
+}
+
+func TestSerialize_MapKeyCollisions(t *testing.T) {
+	m := map[pointKey]int{
+		{Label: "a b", Tag: "c"}: 1,
+		{Label: "a", Tag: "b c"}: 2,
+		{Label: "d", Tag: "e"}:   3,
+	}
+
+	result := Serialize(m).(map[string]interface{})
+	if len(result) != 3 {
+		t.Fatalf("expected every key kept, got %v", result)
+	}
+	// Label "a b" sorts first in Go syntax, so the suffix lands on the other.
+	if result["{a b c}"] != 1 || result["{a b c}#2"] != 2 || result["{d e}"] != 3 {
+		t.Errorf("expected colliding key disambiguated with a suffix, got %v", result)
+	}
+
+	ser := newSerializer(&Config{MapEntriesOnCollision: true})
+	entries, ok := ser.serialize(m).([]interface{})
+	if !ok || len(entries) != 3 {
+		t.Fatalf("expected a key/value list, got %v", ser.serialize(m))
+	}
+	first := entries[0].(map[string]interface{})
+	if key := first["key"].(map[string]interface{}); key["Label"] != "a b" || key["Tag"] != "c" || first["value"] != 1 {
+		t.Errorf("expected structured key and value, got %v", first)
+	}
+	if ser.keyCollisions != 1 {
+		t.Errorf("expected the collision to be counted, got %d", ser.keyCollisions)
+	}
+}
+
+func TestLog_ReportsKeyCollisions(t *testing.T) {
+	read := initCapture(t, Config{})
+
+	Info("clean", map[string]int{"a": 1})
+	Info("collided", map[pointKey]int{{Label: "a b", Tag: "c"}: 1, {Label: "a", Tag: "b c"}: 2})
+
+	entries := read()
+	if _, ok := entries[0].Metadata["keyCollisions"]; ok {
+		t.Error("expected no collision count without collisions")
+	}
+	if entries[1].Metadata["keyCollisions"] != float64(1) {
+		t.Errorf("expected keyCollisions=1, got %v", entries[1].Metadata["keyCollisions"])
+	}
+}
+
+type retryPolicy struct {
+	Attempts int
+	Backoff  string
+}
+
+type syncJob struct {
+	Name     string
+	Retries  int
+	Policy   retryPolicy
+	Tags     []string
+	lastErr  string
+	attempts int
+}
+
+func TestSerialize_OmitZero(t *testing.T) {
+	job := syncJob{Name: "sync", attempts: 2}
+
+	ser := newSerializer(&Config{OmitZero: true})
+	expected := map[string]interface{}{"Name": "sync", "attempts": 2}
+	if result := ser.serialize(job); !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected zero fields omitted, got %v", result)
+	}
+
+	job.Policy.Backoff = "exp"
+	result := ser.serialize(job).(map[string]interface{})
+	if !reflect.DeepEqual(result["Policy"], map[string]interface{}{"Backoff": "exp"}) {
+		t.Errorf("expected a partly set nested struct with its zero fields omitted, got %v", result["Policy"])
+	}
+
+	ser = newSerializer(&Config{})
+	result = ser.serialize(syncJob{}).(map[string]interface{})
+	for _, name := range []string{"Name", "Retries", "Policy", "Tags", "lastErr", "attempts"} {
+		if _, ok := result[name]; !ok {
+			t.Errorf("expected %s without OmitZero, got %v", name, result)
+		}
+	}
+	if !reflect.DeepEqual(result["Policy"], map[string]interface{}{"Attempts": 0, "Backoff": ""}) {
+		t.Errorf("expected the empty nested struct in full, got %v", result["Policy"])
+	}
+}
+
+func TestSerialize_UnexportedAllowlist(t *testing.T) {
+	type audited struct {
+		Name   string
+		reason string
+	}
+	input := struct {
+		Mixed   mixedStruct
+		Audited audited
+	}{
+		Mixed:   mixedStruct{Public: "pub", private: "secret"},
+		Audited: audited{Name: "a", reason: "why"},
+	}
+
+	ser := newSerializer(&Config{UnexportedAllowlist: []reflect.Type{reflect.TypeOf(audited{})}})
+	result := ser.serialize(input).(map[string]interface{})
+
+	mixed := result["Mixed"].(map[string]interface{})
+	if _, ok := mixed["private"]; ok {
+		t.Errorf("expected unexported fields dropped for types not on the allowlist, got %v", mixed)
+	}
+	if mixed["Public"] != "pub" {
+		t.Errorf("expected exported fields kept, got %v", mixed)
+	}
+	if aud := result["Audited"].(map[string]interface{}); aud["reason"] != "why" || aud["Name"] != "a" {
+		t.Errorf("expected unexported fields for an allow-listed type, got %v", aud)
+	}
+
+	if mixed := Serialize(input).(map[string]interface{})["Mixed"].(map[string]interface{}); mixed["private"] != "secret" {
+		t.Errorf("expected an empty allowlist to keep unexported fields, got %v", mixed)
+	}
+}
+
+func TestSerialize_EveryKind(t *testing.T) {
+	ch := make(chan int, 2)
+	var iface fmt.Stringer = reflect.Int
+	n := 7
+
+	cases := map[reflect.Kind]struct {
+		value interface{}
+		want  string
+	}{
+		reflect.Invalid:       {nil, `null`},
+		reflect.Bool:          {true, `true`},
+		reflect.Int:           {int(-1), `-1`},
+		reflect.Int8:          {int8(-8), `-8`},
+		reflect.Int16:         {int16(-16), `-16`},
+		reflect.Int32:         {int32(-32), `-32`},
+		reflect.Int64:         {int64(-64), `-64`},
+		reflect.Uint:          {uint(1), `1`},
+		reflect.Uint8:         {uint8(8), `8`},
+		reflect.Uint16:        {uint16(16), `16`},
+		reflect.Uint32:        {uint32(32), `32`},
+		reflect.Uint64:        {uint64(64), `64`},
+		reflect.Uintptr:       {uintptr(0xff), `"<uintptr 0xff>"`},
+		reflect.Float32:       {float32(1.5), `1.5`},
+		reflect.Float64:       {2.25, `2.25`},
+		reflect.Complex64:     {complex64(1 + 2i), `"(1+2i)"`},
+		reflect.Complex128:    {complex(-0.5, 3), `"(-0.5+3i)"`},
+		reflect.Array:         {[2]int{1, 2}, `[1,2]`},
+		reflect.Chan:          {ch, `"<chan int send-recv len=0 cap=2>"`},
+		reflect.Func:          {(func())(nil), `"<nil func>"`},
+		reflect.Interface:     {&iface, `2`},
+		reflect.Map:           {map[string]int{"a": 1}, `{"a":1}`},
+		reflect.Ptr:           {&n, `7`},
+		reflect.Slice:         {[]string{"x"}, `["x"]`},
+		reflect.String:        {"s", `"s"`},
+		reflect.Struct:        {PublicStruct{Name: "n", Value: 1}, `{"Name":"n","Value":1}`},
+		reflect.UnsafePointer: {unsafe.Pointer(nil), `"<unsafe.Pointer 0x0>"`},
+	}
+
+	for kind := reflect.Invalid; kind <= reflect.UnsafePointer; kind++ {
+		tc, ok := cases[kind]
+		if !ok {
+			t.Errorf("%s: no documented representation", kind)
+			continue
+		}
+		var buf strings.Builder
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(Serialize(tc.value)); err != nil {
+			t.Errorf("%s: not JSON-serializable: %v", kind, err)
+			continue
+		}
+		if data := strings.TrimSpace(buf.String()); data != tc.want {
+			t.Errorf("%s: expected %s, got %s", kind, tc.want, data)
+		}
+	}
+}
+
+type listNode struct {
+	Value int
+	Next  *listNode
+}
+
+func TestSerialize_MaxDepthLongChain(t *testing.T) {
+	var head *listNode
+	for i := 100000; i > 0; i-- {
+		head = &listNode{Value: i, Next: head}
+	}
+
+	// Each node is a pointer hop plus a struct: two levels.
+	ser := newSerializer(&Config{MaxDepth: 50})
+	node := ser.serialize(head)
+	for n := 1; ; n++ {
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			if node != maxDepthMarker {
+				t.Fatalf("expected the chain to end in the depth marker, got %v", node)
+			}
+			if n != 26 {
+				t.Errorf("expected 25 nodes within MaxDepth 50, got %d", n-1)
+			}
+			break
+		}
+		if m["Value"] != n {
+			t.Fatalf("expected node %d, got %v", n, m["Value"])
+		}
+		node = m["Next"]
+	}
+
+	// The default cap applies without configuration.
+	if _, err := json.Marshal(Serialize(head)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type shape interface{ Area() float64 }
+
+type square struct {
+	Side  float64
+	label string
+}
+
+func (s square) Area() float64 { return s.Side * s.Side }
+
+type circle struct{ radius float64 }
+
+func (c *circle) Area() float64 { return 3 * c.radius * c.radius }
+
+type drawing struct {
+	Main    shape
+	backup  shape
+	Ptr     shape
+	ptr     shape
+	Missing shape
+	missing shape
+	shape
+}
+
+func TestSerialize_InterfaceFields(t *testing.T) {
+	input := drawing{
+		Main:   square{Side: 2, label: "main"},
+		backup: square{Side: 3, label: "backup"},
+		Ptr:    &circle{radius: 1},
+		ptr:    &circle{radius: 2},
+		shape:  square{Side: 4, label: "embedded"},
+	}
+
+	result := Serialize(input).(map[string]interface{})
+
+	cases := map[string]map[string]interface{}{
+		"Main":   {"Side": 2.0, "label": "main"},
+		"backup": {"Side": 3.0, "label": "backup"},
+		"Ptr":    {"radius": 1.0},
+		"ptr":    {"radius": 2.0},
+	}
+	for field, want := range cases {
+		got, ok := result[field].(map[string]interface{})
+		if !ok {
+			t.Errorf("%s: expected the concrete value's fields, got %v", field, result[field])
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected %v, got %v", field, want, got)
+		}
+	}
+	for _, field := range []string{"Missing", "missing"} {
+		if v, ok := result[field]; !ok || v != nil {
+			t.Errorf("%s: expected nil interface to be null, got %v", field, v)
+		}
+	}
+	// Unexported embedded fields are skipped like any other.
+	if _, ok := result["shape"]; ok {
+		t.Errorf("expected unexported embedded interface to be skipped, got %v", result["shape"])
+	}
+}
+
+func TestSerialize_NestedCollections(t *testing.T) {
+	shared := &mixedStruct{Public: "shared"}
+	arr := [3]string{"a", "b", "c"}
+	input := struct {
+		Grid   [][]int
+		Rows   []map[string]int
+		Arr    *[3]string
+		Slice  []string
+		Groups map[string][]*mixedStruct
+	}{
+		Grid:  [][]int{{1, 2}, {3}, nil, {}},
+		Rows:  []map[string]int{{"a": 1}, {"b": 2, "c": 3}},
+		Arr:   &arr,
+		Slice: arr[:2],
+		Groups: map[string][]*mixedStruct{
+			"x": {shared, shared},
+			"y": {shared, nil},
+		},
+	}
+
+	result := Serialize(input).(map[string]interface{})
+
+	grid := result["Grid"].([]interface{})
+	if !reflect.DeepEqual(grid[0], []interface{}{1, 2}) || !reflect.DeepEqual(grid[1], []interface{}{3}) {
+		t.Errorf("unexpected grid rows %v", grid)
+	}
+	if grid[2] != nil || !reflect.DeepEqual(grid[3], []interface{}{}) {
+		t.Errorf("expected nil row as null and empty row as [], got %v and %v", grid[2], grid[3])
+	}
+
+	rows := result["Rows"].([]interface{})
+	if rows[1].(map[string]interface{})["c"] != 3 {
+		t.Errorf("unexpected rows %v", rows)
+	}
+
+	// A pointer to an array and a slice of the same array aren't a cycle.
+	if !reflect.DeepEqual(result["Arr"], []interface{}{"a", "b", "c"}) {
+		t.Errorf("expected pointer to array as a list, got %v", result["Arr"])
+	}
+	if !reflect.DeepEqual(result["Slice"], []interface{}{"a", "b"}) {
+		t.Errorf("expected slice of the same array, got %v", result["Slice"])
+	}
+
+	// Shared pointers within and across slices are repeated, not circular.
+	groups := result["Groups"].(map[string]interface{})
+	for _, key := range []string{"x", "y"} {
+		first := groups[key].([]interface{})[0].(map[string]interface{})
+		if first["Public"] != "shared" {
+			t.Errorf("%s: expected shared struct, got %v", key, first)
+		}
+	}
+	if second := groups["x"].([]interface{})[1]; second == "[circular]" {
+		t.Error("expected a repeated sibling pointer not to be marked circular")
+	}
+	if groups["y"].([]interface{})[1] != nil {
+		t.Errorf("expected nil pointer element as null, got %v", groups["y"].([]interface{})[1])
+	}
+}
+
+func TestSerialize_NestedCollectionsCapPerLevel(t *testing.T) {
+	inner := map[string]int{}
+	for i := 0; i < 5; i++ {
+		inner[fmt.Sprintf("k%d", i)] = i
+	}
+	input := []map[string]map[string]int{{"a": inner, "b": inner, "c": inner}}
+
+	ser := newSerializer(&Config{MaxObjectKeys: 2})
+	outer := ser.serialize(input).([]interface{})[0].(map[string]interface{})
+
+	if len(outer) != 3 || outer[omittedKey] != "[1 more keys]" {
+		t.Errorf("expected the outer map capped at 2 keys, got %v", outer)
+	}
+	nested := outer["a"].(map[string]interface{})
+	if len(nested) != 3 || nested[omittedKey] != "[3 more keys]" {
+		t.Errorf("expected each nested map capped independently, got %v", nested)
+	}
+
+	// Each slice level counts toward MaxDepth.
+	cube := [][][]int{{{1}}}
+	ser = newSerializer(&Config{MaxDepth: 2})
+	if got := ser.serialize(cube); !reflect.DeepEqual(got, []interface{}{[]interface{}{maxDepthMarker}}) {
+		t.Errorf("expected the third level cut off, got %v", got)
+	}
+}
@@ -0,0 +1,375 @@
+package slogx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// client is a connected viewer. WebSocket and SSE viewers share the same
+// bookkeeping and only differ in how a frame is written to them.
+type client struct {
+	remoteAddr string
+	writeMu    sync.Mutex
+	send       func(payload []byte) error
+	// sendBinary writes a binary frame; nil for transports without them.
+	sendBinary func(payload []byte) error
+	close      func()
+
+	prefsMu sync.RWMutex
+	prefs   clientPreferences
+
+	// removed is set under writeMu once the client leaves the client set, so
+	// a broadcast working from an older snapshot can't write to it.
+	removed bool
+
+	// replayed holds the sequence numbers already sent on resume. It is set
+	// before the client is registered and never changed afterwards.
+	replayed map[uint64]bool
+
+	// queue, when Config.ClientQueueSize is set, decouples broadcasts from
+	// writes; see startQueue. stop ends the draining goroutine, and
+	// dropSignal wakes it to report drops.
+	queue      chan queuedFrame
+	stop       chan struct{}
+	dropped    atomic.Uint64
+	dropSignal chan struct{}
+}
+
+// errClientRemoved is returned when writing to a client that has left.
+var errClientRemoved = errors.New("slogx: client removed")
+
+// write sends a single text frame, serializing concurrent writers.
+func (c *client) write(payload []byte) error {
+	return c.writeFrame(payload, false)
+}
+
+// writeFrame sends a text or binary frame.
+func (c *client) writeFrame(payload []byte, binary bool) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.writeFrameLocked(payload, binary)
+}
+
+// writeFrameLocked is writeFrame for callers already holding writeMu.
+func (c *client) writeFrameLocked(payload []byte, binary bool) error {
+	if c.removed {
+		return errClientRemoved
+	}
+	if binary {
+		return c.sendBinary(payload)
+	}
+	return c.send(payload)
+}
+
+func (s *SlogX) addClient(c *client) {
+	s.clientsMu.Lock()
+	s.registerLocked(c)
+	s.clientsMu.Unlock()
+
+	if hook := s.config.OnClientConnect; hook != nil {
+		go hook(c.remoteAddr)
+	}
+}
+
+// registerLocked adds c to the client set and wakes WaitForClient callers.
+// clientsMu must be held for writing.
+func (s *SlogX) registerLocked(c *client) {
+	if size := s.config.ClientQueueSize; size > 0 {
+		s.startQueue(c, size)
+	}
+	s.clients[c] = true
+	close(s.clientJoined)
+	s.clientJoined = make(chan struct{})
+}
+
+// WaitForClient blocks until at least one viewer is connected, so early
+// entries aren't missed, or returns the context's error once it is done.
+func WaitForClient(ctx context.Context) error {
+	s := getInstance()
+	for {
+		s.clientsMu.RLock()
+		connected := len(s.clients) > 0
+		joined := s.clientJoined
+		s.clientsMu.RUnlock()
+
+		if connected {
+			return nil
+		}
+		select {
+		case <-joined:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *SlogX) removeClient(c *client) {
+	s.clientsMu.Lock()
+	delete(s.clients, c)
+	s.clientsMu.Unlock()
+
+	// Wait out any in-flight write; later ones see removed and skip.
+	c.writeMu.Lock()
+	c.removed = true
+	c.writeMu.Unlock()
+	if c.stop != nil {
+		close(c.stop)
+	}
+
+	if hook := s.config.OnClientDisconnect; hook != nil {
+		go hook(c.remoteAddr)
+	}
+}
+
+// snapshotClients copies the client set so callers can iterate it without
+// holding clientsMu while clients connect and disconnect.
+func (s *SlogX) snapshotClients() []*client {
+	s.clientsMu.RLock()
+	defer s.clientsMu.RUnlock()
+
+	clients := make([]*client, 0, len(s.clients))
+	for c := range s.clients {
+		clients = append(clients, c)
+	}
+	return clients
+}
+
+// closeClients disconnects every client. Each client's own cleanup removes
+// it from the client set.
+func (s *SlogX) closeClients() {
+	for _, c := range s.snapshotClients() {
+		c.close()
+	}
+}
+
+// broadcast writes an entry's frame to every client that wants it and
+// returns how many writes succeeded. Queued clients only count when
+// opts.ack is set, in which case broadcast waits for them to write the
+// frame. Once opts.ctx is done, the remaining clients are skipped.
+func (s *SlogX) broadcast(entry *LogEntry, payload []byte, opts entryOptions) (delivered int) {
+	service, _ := entry.Metadata[s.metaKey("service")].(string)
+	// Queued frames outlive the pooled payload buffer; copy it once for all
+	// queues. The MessagePack form is likewise encoded once, on first use.
+	var queued, packed []byte
+	defaultFormat := s.config.wireFormat()
+	var pending []*client
+	var results []chan error
+	for _, c := range s.snapshotClients() {
+		if !c.wants(entry, service) || c.replayed[entry.Seq] {
+			continue
+		}
+		if opts.ctx != nil && opts.ctx.Err() != nil {
+			s.skipped.Add(1)
+			continue
+		}
+		binary := c.binary(defaultFormat)
+		if binary && packed == nil {
+			var err error
+			if packed, err = msgpackFromJSON(payload); err != nil {
+				continue
+			}
+		}
+		if c.queue != nil {
+			f := queuedFrame{binary: binary}
+			if binary {
+				f.payload = packed
+			} else {
+				if queued == nil {
+					queued = append([]byte(nil), payload...)
+				}
+				f.payload = queued
+			}
+			if opts.ack {
+				f.written = make(chan error, 1)
+			}
+			if c.enqueue(f) && opts.ack {
+				pending = append(pending, c)
+				results = append(results, f.written)
+			}
+			continue
+		}
+		frame := payload
+		if binary {
+			frame = packed
+		}
+		if err := c.writeFrame(frame, binary); err != nil {
+			c.drop(err)
+			continue
+		}
+		delivered++
+	}
+
+	for i, c := range pending {
+		select {
+		case err := <-results[i]:
+			if err == nil {
+				delivered++
+			}
+		case <-c.stop:
+		}
+	}
+	return delivered
+}
+
+// Handler returns the log server's routes (WebSocket at `/`, SSE at
+// `/events`, blobs at `/blob/{id}`, and the optional viewer at `/viewer`)
+// for mounting on an existing server. Pair it with Config.NoServer.
+// Requests are rejected unless the server is enabled, via IsDev or
+// EnableServer.
+func Handler() http.Handler {
+	s := getInstance()
+	h := s.handler()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.config.serverEnabled() {
+			http.NotFound(w, r)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// handler builds the HTTP routes served by the log server.
+func (s *SlogX) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", s.handleEvents)
+	mux.HandleFunc("/viewer", s.handleViewer)
+	mux.HandleFunc("/blob/", s.handleBlob)
+	mux.HandleFunc("/", s.handleWebSocket)
+	return mux
+}
+
+func (s *SlogX) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	c := &client{
+		remoteAddr: conn.RemoteAddr().String(),
+		send: func(payload []byte) error {
+			conn.SetWriteDeadline(time.Now().Add(s.config.writeTimeout()))
+			return conn.WriteMessage(websocket.TextMessage, payload)
+		},
+		sendBinary: func(payload []byte) error {
+			conn.SetWriteDeadline(time.Now().Add(s.config.writeTimeout()))
+			return conn.WriteMessage(websocket.BinaryMessage, payload)
+		},
+		close: func() { conn.Close() },
+	}
+
+	// The handshake must be the first frame, so send it before the client
+	// can receive broadcasts.
+	if err := c.write(s.handshakeFrame()); err != nil {
+		conn.Close()
+		return
+	}
+	if lastSeq, ok := resumeFrom(r); ok {
+		s.replayTo(c, lastSeq)
+		if hook := s.config.OnClientConnect; hook != nil {
+			go hook(c.remoteAddr)
+		}
+	} else {
+		s.addClient(c)
+	}
+
+	var expiry *time.Timer
+	if age := s.config.MaxConnectionAge; age > 0 {
+		expiry = time.AfterFunc(age, func() {
+			// Taking writeMu waits out an in-flight write, so the close
+			// frame can't interleave with an entry.
+			c.writeMu.Lock()
+			if !c.removed {
+				msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "max connection age")
+				conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(s.config.writeTimeout()))
+			}
+			c.writeMu.Unlock()
+			conn.Close()
+		})
+	}
+
+	// A frame over the limit makes the read fail with a "message too big"
+	// close sent to the viewer. A close frame from the viewer is answered by
+	// the connection's close handler and also ends the read; either way the
+	// client is removed at once rather than on its next failed write.
+	conn.SetReadLimit(s.config.maxMessageSize())
+	go func() {
+		defer func() {
+			if expiry != nil {
+				expiry.Stop()
+			}
+			s.removeClient(c)
+			conn.Close()
+		}()
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				break
+			}
+			c.applyPreferences(data)
+		}
+	}()
+}
+
+// handleEvents streams entries as Server-Sent Events for viewers that sit
+// behind proxies which block WebSocket upgrades.
+func (s *SlogX) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	done := make(chan struct{})
+	var closeOnce sync.Once
+	rc := http.NewResponseController(w)
+
+	c := &client{
+		remoteAddr: r.RemoteAddr,
+		send: func(payload []byte) error {
+			// Indented JSON spans lines; each needs its own data: prefix.
+			data := bytes.ReplaceAll(payload, []byte("\n"), []byte("\ndata: "))
+			rc.SetWriteDeadline(time.Now().Add(s.config.writeTimeout()))
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+				return err
+			}
+			return rc.Flush()
+		},
+		close: func() { closeOnce.Do(func() { close(done) }) },
+	}
+	s.addClient(c)
+	// Removal waits for the write lock and marks the client removed, so no
+	// broadcast can still be writing to w once this handler returns.
+	defer s.removeClient(c)
+
+	// Let the viewer know the stream is live before the first entry arrives.
+	c.writeMu.Lock()
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+	c.writeMu.Unlock()
+
+	var expired <-chan time.Time
+	if age := s.config.MaxConnectionAge; age > 0 {
+		expiry := time.NewTimer(age)
+		defer expiry.Stop()
+		expired = expiry.C
+	}
+
+	select {
+	case <-r.Context().Done():
+	case <-done:
+	case <-expired:
+	}
+}
@@ -0,0 +1,1017 @@
+package slogx
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// startTestServer serves a fresh instance's routes on an httptest server.
+func startTestServer(t *testing.T) (*SlogX, *httptest.Server) {
+	t.Helper()
+	resetInstance()
+	s := getInstance()
+	srv := httptest.NewServer(s.handler())
+	t.Cleanup(func() {
+		srv.Close()
+		resetInstance()
+	})
+	return s, srv
+}
+
+// readSSEData returns the payload of the next `data:` frame, skipping comments.
+func readSSEData(t *testing.T, reader *bufio.Reader) string {
+	t.Helper()
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("failed to read SSE stream: %v", err)
+		}
+		if strings.HasPrefix(line, "data: ") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "data: "))
+		}
+	}
+}
+
+func TestSSE_StreamsEntries(t *testing.T) {
+	_, srv := startTestServer(t)
+
+	resp, err := http.Get(srv.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("expected text/event-stream, got %q", ct)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+
+	// The connected comment is only written once the client is registered.
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != ": connected\n" {
+		t.Fatalf("expected connected comment, got %q", line)
+	}
+
+	Info("first", map[string]interface{}{"n": 1})
+	Warn("second")
+
+	var first, second LogEntry
+	if err := json.Unmarshal([]byte(readSSEData(t, reader)), &first); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(readSSEData(t, reader)), &second); err != nil {
+		t.Fatal(err)
+	}
+
+	if first.Level != INFO || first.Args[0] != "first" {
+		t.Errorf("unexpected first entry: %+v", first)
+	}
+	if second.Level != WARN || second.Args[0] != "second" {
+		t.Errorf("unexpected second entry: %+v", second)
+	}
+}
+
+// waitForClients blocks until the instance has n registered clients.
+func waitForClients(t *testing.T, s *SlogX, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		s.clientsMu.RLock()
+		count := len(s.clients)
+		s.clientsMu.RUnlock()
+		if count == n {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d clients", n)
+}
+
+// dialClient connects a WebSocket client and consumes the handshake frame.
+func dialClient(t *testing.T, url string) *websocket.Conn {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, _, err := conn.ReadMessage(); err != nil {
+		t.Fatalf("failed to read handshake: %v", err)
+	}
+	return conn
+}
+
+// wsURL converts an httptest server URL into its WebSocket equivalent.
+func wsURL(srv *httptest.Server) string {
+	return "ws" + strings.TrimPrefix(srv.URL, "http") + "/"
+}
+
+// readEntry reads the next WebSocket frame as a LogEntry.
+func readEntry(t *testing.T, conn *websocket.Conn) LogEntry {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("failed to read entry: %v", err)
+	}
+	var entry LogEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatal(err)
+	}
+	return entry
+}
+
+func TestHandler_MountedOnExistingMux(t *testing.T) {
+	resetInstance()
+	t.Cleanup(resetInstance)
+
+	ciMode := false
+	Init(Config{IsDev: true, NoServer: true, CIMode: &ciMode})
+
+	mux := http.NewServeMux()
+	mux.Handle("/slogx/", http.StripPrefix("/slogx", Handler()))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	conn := dialClient(t, "ws"+strings.TrimPrefix(srv.URL, "http")+"/slogx/")
+	waitForClients(t, getInstance(), 1)
+
+	Info("through mounted handler")
+
+	entry := readEntry(t, conn)
+	if entry.Args[0] != "through mounted handler" {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+}
+
+func TestHandler_RequiresIsDev(t *testing.T) {
+	resetInstance()
+	t.Cleanup(resetInstance)
+
+	Init(Config{IsDev: false})
+
+	srv := httptest.NewServer(Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 without IsDev, got %d", resp.StatusCode)
+	}
+}
+
+func TestInit_WithListener(t *testing.T) {
+	resetInstance()
+	t.Cleanup(resetInstance)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+
+	ciMode := false
+	Init(Config{IsDev: true, CIMode: &ciMode, Listener: listener})
+
+	conn := dialClient(t, "ws://"+addr+"/")
+	waitForClients(t, getInstance(), 1)
+
+	Info("via listener")
+	if entry := readEntry(t, conn); entry.Args[0] != "via listener" {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown failed: %v", err)
+	}
+
+	// The client is disconnected and the listener no longer accepts.
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, _, err := conn.ReadMessage(); err == nil {
+		t.Error("expected client connection to be closed")
+	}
+	if c, err := net.Dial("tcp", addr); err == nil {
+		c.Close()
+		t.Error("expected listener to be closed")
+	}
+}
+
+func TestInitReady(t *testing.T) {
+	resetInstance()
+	t.Cleanup(resetInstance)
+
+	// Reserve a port to bind by number; another listener keeps it busy
+	// for the error case below.
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+	port := busy.Addr().(*net.TCPAddr).Port
+
+	enabled := true
+	if _, _, err := InitReady(Config{EnableServer: &enabled, Port: port}); err == nil {
+		t.Fatal("expected an error for a port already in use")
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, ready, err := InitReady(Config{EnableServer: &enabled, Listener: listener})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr != listener.Addr().String() {
+		t.Errorf("expected address %s, got %s", listener.Addr(), addr)
+	}
+	select {
+	case <-ready:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for the server to be ready")
+	}
+
+	// No retries: the first dial must succeed.
+	conn, _, err := websocket.DefaultDialer.Dial("ws://"+addr+"/", nil)
+	if err != nil {
+		t.Fatalf("expected to connect once ready, got %v", err)
+	}
+	conn.Close()
+}
+
+func TestInitReady_WithoutServer(t *testing.T) {
+	resetInstance()
+	t.Cleanup(resetInstance)
+
+	addr, ready, err := InitReady(Config{Console: io.Discard})
+	if err != nil || addr != "" {
+		t.Fatalf("expected no address and no error, got %q, %v", addr, err)
+	}
+	select {
+	case <-ready:
+	default:
+		t.Error("expected ready to be closed when there is no server")
+	}
+}
+
+func TestHandshake_SentOnConnect(t *testing.T) {
+	s, srv := startTestServer(t)
+	s.serviceName.Store("handshake-svc")
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL(srv), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var hs handshake
+	if err := json.Unmarshal(data, &hs); err != nil {
+		t.Fatal(err)
+	}
+	if hs.Slogx != protocolVersion {
+		t.Errorf("expected protocol version %d, got %d", protocolVersion, hs.Slogx)
+	}
+	if hs.Service != "handshake-svc" {
+		t.Errorf("expected service handshake-svc, got %q", hs.Service)
+	}
+	if len(hs.Features) == 0 {
+		t.Error("expected features to be announced")
+	}
+	if hs.Type != "hello" {
+		t.Errorf("expected a hello frame, got type %q", hs.Type)
+	}
+	if hs.MetadataPrefix != "" {
+		t.Errorf("expected no metadata prefix by default, got %q", hs.MetadataPrefix)
+	}
+	if hs.PID != os.Getpid() || hs.GoVersion != runtime.Version() || hs.NumCPU != runtime.NumCPU() {
+		t.Errorf("expected process details, got %+v", hs)
+	}
+	if host, _ := os.Hostname(); hs.Hostname != host {
+		t.Errorf("expected hostname %q, got %q", host, hs.Hostname)
+	}
+	if _, err := time.Parse(time.RFC3339Nano, hs.StartedAt); err != nil {
+		t.Errorf("expected an RFC 3339 start time, got %q", hs.StartedAt)
+	}
+}
+
+func TestHandshake_AnnouncesMetadataPrefix(t *testing.T) {
+	s, srv := startTestServer(t)
+	s.config.MetadataPrefix = "slogx_"
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL(srv), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hs handshake
+	if err := json.Unmarshal(data, &hs); err != nil || hs.MetadataPrefix != "slogx_" {
+		t.Errorf("expected the metadata prefix in the handshake, got %s: %v", data, err)
+	}
+}
+
+func TestHandshake_ClientReplyConfiguresConnection(t *testing.T) {
+	s, srv := startTestServer(t)
+
+	filtered := dialClient(t, wsURL(srv))
+	unfiltered := dialClient(t, wsURL(srv))
+	waitForClients(t, s, 2)
+
+	// Unknown fields are ignored.
+	reply := `{"minLevel":"WARN","futureOption":true}`
+	if err := filtered.WriteMessage(websocket.TextMessage, []byte(reply)); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		s.clientsMu.RLock()
+		defer s.clientsMu.RUnlock()
+		for c := range s.clients {
+			c.prefsMu.RLock()
+			applied := c.prefs.MinLevel == WARN
+			c.prefsMu.RUnlock()
+			if applied {
+				return true
+			}
+		}
+		return false
+	})
+
+	Info("info entry")
+	Warn("warn entry")
+
+	if entry := readEntry(t, filtered); entry.Args[0] != "warn entry" {
+		t.Errorf("expected filtered client to skip INFO, got %v", entry.Args)
+	}
+	if entry := readEntry(t, unfiltered); entry.Args[0] != "info entry" {
+		t.Errorf("expected unfiltered client to receive INFO, got %v", entry.Args)
+	}
+}
+
+// waitFor polls cond until it holds or the test times out.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("timed out waiting for condition")
+}
+
+func TestOnClientCallbacks(t *testing.T) {
+	s, srv := startTestServer(t)
+
+	connected := make(chan string, 1)
+	disconnected := make(chan string, 1)
+	s.config.OnClientConnect = func(addr string) { connected <- addr }
+	s.config.OnClientDisconnect = func(addr string) { disconnected <- addr }
+
+	conn := dialClient(t, wsURL(srv))
+	localAddr := conn.LocalAddr().String()
+
+	select {
+	case addr := <-connected:
+		if addr != localAddr {
+			t.Errorf("expected connect address %s, got %s", localAddr, addr)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnClientConnect was not called")
+	}
+
+	conn.Close()
+
+	select {
+	case addr := <-disconnected:
+		if addr != localAddr {
+			t.Errorf("expected disconnect address %s, got %s", localAddr, addr)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnClientDisconnect was not called")
+	}
+}
+
+func TestWebSocket_OversizedFrameCloses(t *testing.T) {
+	s, srv := startTestServer(t)
+	s.config.MaxMessageSize = 64
+
+	conn := dialClient(t, wsURL(srv))
+	waitForClients(t, s, 1)
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(strings.Repeat("x", 65))); err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err := conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseMessageTooBig) {
+		t.Errorf("expected a message too big close, got %v", err)
+	}
+	waitForClients(t, s, 0)
+}
+
+func TestWebSocket_CloseFrameEvictsClient(t *testing.T) {
+	s, srv := startTestServer(t)
+
+	conn := dialClient(t, wsURL(srv))
+	waitForClients(t, s, 1)
+
+	// The connection stays open on our side; only the close frame is sent.
+	msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "bye")
+	if err := conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	waitForClients(t, s, 0)
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Errorf("expected the close to be echoed, got %v", err)
+	}
+}
+
+func TestMaxConnectionAge_ClosesNormally(t *testing.T) {
+	s, srv := startTestServer(t)
+	s.config.MaxConnectionAge = 100 * time.Millisecond
+
+	conn := dialClient(t, wsURL(srv))
+	waitForClients(t, s, 1)
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err := conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Errorf("expected a normal close once the connection aged out, got %v", err)
+	}
+	waitForClients(t, s, 0)
+
+	// A new connection gets a fresh lifetime.
+	dialClient(t, wsURL(srv))
+	waitForClients(t, s, 1)
+}
+
+// readRaw reads the next WebSocket frame without decoding it.
+func readRaw(t *testing.T, conn *websocket.Conn) string {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("failed to read frame: %v", err)
+	}
+	return string(data)
+}
+
+func TestJSONOptions_HTMLEscaping(t *testing.T) {
+	s, srv := startTestServer(t)
+	conn := dialClient(t, wsURL(srv))
+	waitForClients(t, s, 1)
+
+	payload := "<script>alert('xss')</script>"
+
+	Info(payload)
+	if raw := readRaw(t, conn); !strings.Contains(raw, `\u003cscript\u003e`) {
+		t.Errorf("expected HTML escaping by default, got %s", raw)
+	}
+
+	s.config.DisableHTMLEscape = true
+	Info(payload)
+	raw := readRaw(t, conn)
+	if !strings.Contains(raw, "<script>") {
+		t.Errorf("expected unescaped payload, got %s", raw)
+	}
+
+	var entry LogEntry
+	if err := json.Unmarshal([]byte(raw), &entry); err != nil {
+		t.Fatalf("expected unescaped frame to remain valid JSON: %v", err)
+	}
+	if entry.Args[0] != payload {
+		t.Errorf("expected payload to round-trip, got %v", entry.Args[0])
+	}
+}
+
+func TestJSONOptions_Indent(t *testing.T) {
+	s, srv := startTestServer(t)
+	s.config.JSONIndent = "  "
+
+	conn := dialClient(t, wsURL(srv))
+	resp, err := http.Get(srv.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+	waitForClients(t, s, 2)
+
+	Info("pretty")
+
+	if raw := readRaw(t, conn); !strings.Contains(raw, "\n  \"id\"") {
+		t.Errorf("expected indented JSON, got %s", raw)
+	}
+
+	// Every line of an indented SSE frame is its own data: field.
+	var lines []string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line == "\n" && len(lines) > 0 {
+			break
+		}
+		if strings.HasPrefix(line, "data: ") {
+			lines = append(lines, strings.TrimSuffix(strings.TrimPrefix(line, "data: "), "\n"))
+		}
+	}
+	var entry LogEntry
+	if err := json.Unmarshal([]byte(strings.Join(lines, "\n")), &entry); err != nil {
+		t.Fatalf("expected SSE data lines to join into valid JSON: %v", err)
+	}
+}
+
+func TestResume_ReplaysMissedEntries(t *testing.T) {
+	s, srv := startTestServer(t)
+	s.replay = newReplayBuffer(100)
+
+	conn := dialClient(t, wsURL(srv))
+	waitForClients(t, s, 1)
+
+	var lastSeq uint64
+	for i := 0; i < 3; i++ {
+		Info("before disconnect", i)
+		lastSeq = readEntry(t, conn).Seq
+	}
+	conn.Close()
+	waitForClients(t, s, 0)
+
+	Info("missed", 0)
+	Info("missed", 1)
+
+	resumed := dialClient(t, fmt.Sprintf("%s?lastSeq=%d", wsURL(srv), lastSeq))
+	waitForClients(t, s, 1)
+	Info("live")
+
+	for i := 0; i < 2; i++ {
+		entry := readEntry(t, resumed)
+		if entry.Seq != lastSeq+uint64(i)+1 || entry.Args[0] != "missed" {
+			t.Fatalf("expected missed entry with seq %d, got %+v", lastSeq+uint64(i)+1, entry)
+		}
+	}
+	if entry := readEntry(t, resumed); entry.Args[0] != "live" {
+		t.Fatalf("expected live entry after the replay, got %+v", entry)
+	}
+}
+
+func TestResume_ReplaysInWireFormat(t *testing.T) {
+	s, srv := startTestServer(t)
+	s.config.WireFormat = WireMsgPack
+	s.replay = newReplayBuffer(2)
+
+	for i := 0; i < 3; i++ {
+		Info("entry", i)
+	}
+
+	conn := dialClient(t, wsURL(srv)+"?lastSeq=0")
+	for _, want := range []string{"truncated", "entry", "entry"} {
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		kind, data, err := conn.ReadMessage()
+		if err != nil || kind != websocket.BinaryMessage {
+			t.Fatalf("expected a binary %s frame, got kind %d: %v", want, kind, err)
+		}
+		frame := decodeMsgpack(t, bytes.NewReader(data)).(map[string]interface{})
+		if want == "truncated" && frame["metadata"].(map[string]interface{})["truncated"] != true {
+			t.Errorf("expected the truncated marker first, got %v", frame)
+		}
+		if want == "entry" && frame["args"].([]interface{})[0] != "entry" {
+			t.Errorf("expected a replayed entry, got %v", frame)
+		}
+	}
+}
+
+func TestResume_MarksTruncatedGap(t *testing.T) {
+	s, srv := startTestServer(t)
+	s.replay = newReplayBuffer(2)
+
+	for i := 0; i < 5; i++ {
+		Info("entry", i)
+	}
+
+	conn := dialClient(t, wsURL(srv)+"?lastSeq=1")
+
+	marker := readEntry(t, conn)
+	if marker.Metadata["truncated"] != true || marker.Metadata["missed"] != float64(2) {
+		t.Fatalf("expected a truncated marker for 2 entries, got %+v", marker)
+	}
+	for _, want := range []uint64{4, 5} {
+		if entry := readEntry(t, conn); entry.Seq != want {
+			t.Fatalf("expected seq %d, got %d", want, entry.Seq)
+		}
+	}
+}
+
+func TestBroadcast_ClientChurnWhileLogging(t *testing.T) {
+	s, srv := startTestServer(t)
+	healthy := dialClient(t, wsURL(srv))
+	waitForClients(t, s, 1)
+
+	stop := make(chan struct{})
+	var churn sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		churn.Add(1)
+		go func() {
+			defer churn.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				conn, _, err := websocket.DefaultDialer.Dial(wsURL(srv), nil)
+				if err != nil {
+					continue
+				}
+				conn.Close()
+			}
+		}()
+	}
+
+	const total = 500
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < total; i++ {
+			Info("churn", i)
+		}
+	}()
+
+	for i := 0; i < total; i++ {
+		entry := readEntry(t, healthy)
+		if entry.Args[1] != float64(i) {
+			t.Fatalf("expected entry %d, got %v", i, entry.Args)
+		}
+	}
+	<-done
+	close(stop)
+	churn.Wait()
+}
+
+func TestWriteTimeout_EvictsStalledClient(t *testing.T) {
+	s, srv := startTestServer(t)
+	s.config.WriteTimeout = 100 * time.Millisecond
+
+	// Connect but never read, so the socket buffers eventually fill up.
+	stalled, _, err := websocket.DefaultDialer.Dial(wsURL(srv), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stalled.Close()
+	waitForClients(t, s, 1)
+
+	big := strings.Repeat("x", 1<<20)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		start := time.Now()
+		Info(big)
+		if took := time.Since(start); took > time.Second {
+			t.Fatalf("expected the write to time out quickly, blocked for %v", took)
+		}
+
+		s.clientsMu.RLock()
+		n := len(s.clients)
+		s.clientsMu.RUnlock()
+		if n == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the stalled client to be evicted")
+		}
+	}
+}
+
+func TestPreferences_ServiceFilter(t *testing.T) {
+	s, srv := startTestServer(t)
+	conn := dialClient(t, wsURL(srv))
+	waitForClients(t, s, 1)
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"services":["billing"]}`)); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		for _, c := range s.snapshotClients() {
+			c.prefsMu.RLock()
+			applied := len(c.prefs.Services) == 1
+			c.prefsMu.RUnlock()
+			if applied {
+				return true
+			}
+		}
+		return false
+	})
+
+	SetServiceName("search")
+	Info("from search")
+	SetServiceName("billing")
+	Info("from billing")
+
+	if entry := readEntry(t, conn); entry.Args[0] != "from billing" {
+		t.Errorf("expected only billing entries, got %v from %v", entry.Args, entry.Metadata["service"])
+	}
+
+	// An empty list subscribes to every service again.
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"services":[]}`)); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		for _, c := range s.snapshotClients() {
+			c.prefsMu.RLock()
+			cleared := len(c.prefs.Services) == 0
+			c.prefsMu.RUnlock()
+			if cleared {
+				return true
+			}
+		}
+		return false
+	})
+	SetServiceName("search")
+	Info("search again")
+	if entry := readEntry(t, conn); entry.Args[0] != "search again" {
+		t.Errorf("expected the cleared filter to pass every service, got %v", entry.Args)
+	}
+}
+
+func TestPreferences_ComponentFilter(t *testing.T) {
+	s, srv := startTestServer(t)
+	conn := dialClient(t, wsURL(srv))
+	waitForClients(t, s, 1)
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"components":["db"]}`)); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		for _, c := range s.snapshotClients() {
+			c.prefsMu.RLock()
+			applied := len(c.prefs.Components) == 1
+			c.prefsMu.RUnlock()
+			if applied {
+				return true
+			}
+		}
+		return false
+	})
+
+	Info(Component("http"), "request done")
+	Info("untagged")
+	Info(Component("db"), "query done")
+
+	entry := readEntry(t, conn)
+	if entry.Args[0] != "query done" || entry.Component != "db" {
+		t.Errorf("expected only db entries, got %v from %q", entry.Args, entry.Component)
+	}
+}
+
+func TestWaitForClient(t *testing.T) {
+	_, srv := startTestServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := WaitForClient(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected a timeout without clients, got %v", err)
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		result <- WaitForClient(ctx)
+	}()
+
+	dialClient(t, wsURL(srv))
+	if err := <-result; err != nil {
+		t.Fatalf("expected WaitForClient to return once a client connects, got %v", err)
+	}
+
+	// Already connected: returns immediately.
+	if err := WaitForClient(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClientQueue_ReportsDroppedEntries(t *testing.T) {
+	s, _ := startTestServer(t)
+	s.config.ClientQueueSize = 2
+
+	// A viewer that stalls on its first write until released.
+	gate := make(chan struct{})
+	frames := make(chan []byte, 100)
+	c := &client{
+		remoteAddr: "slow",
+		send: func(payload []byte) error {
+			<-gate
+			frames <- append([]byte(nil), payload...)
+			return nil
+		},
+		close: func() {},
+	}
+	s.addClient(c)
+	t.Cleanup(func() { s.removeClient(c) })
+
+	const total = 20
+	for i := 0; i < total; i++ {
+		Info("burst", i)
+	}
+	close(gate)
+
+	delivered, dropped := 0, 0
+	for delivered+dropped < total {
+		select {
+		case data := <-frames:
+			var entry LogEntry
+			if err := json.Unmarshal(data, &entry); err != nil {
+				t.Fatal(err)
+			}
+			if entry.Metadata["system"] == true {
+				dropped += int(entry.Metadata["dropped"].(float64))
+				if entry.Level != WARN || !strings.HasPrefix(entry.Args[0].(string), "slogx dropped ") {
+					t.Errorf("unexpected drop notification %+v", entry)
+				}
+				continue
+			}
+			delivered++
+		case <-time.After(2 * time.Second):
+			t.Fatalf("expected every entry delivered or reported, got %d delivered and %d dropped", delivered, dropped)
+		}
+	}
+	if dropped == 0 {
+		t.Error("expected a full queue to drop entries")
+	}
+}
+
+func TestClientQueue_ReportsDropsWhenLoggingStops(t *testing.T) {
+	s, _ := startTestServer(t)
+	s.config.ClientQueueSize = 1
+
+	gate := make(chan struct{})
+	frames := make(chan []byte, 100)
+	c := &client{
+		remoteAddr: "slow",
+		send: func(payload []byte) error {
+			<-gate
+			frames <- append([]byte(nil), payload...)
+			return nil
+		},
+		close: func() {},
+	}
+	s.addClient(c)
+	t.Cleanup(func() { s.removeClient(c) })
+
+	Info("first")
+	waitFor(t, func() bool { return len(c.queue) == 0 })
+	Info("queued")
+	c.dropped.Add(2) // as if the queue had been full for two more entries
+	c.dropSignal <- struct{}{}
+	close(gate)
+
+	var got []string
+	for len(got) < 3 {
+		select {
+		case data := <-frames:
+			var entry LogEntry
+			if err := json.Unmarshal(data, &entry); err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, fmt.Sprint(entry.Args[0]))
+		case <-time.After(2 * time.Second):
+			t.Fatalf("expected the drops reported without further logging, got %v", got)
+		}
+	}
+	if want := []string{"first", "slogx dropped 2 entries", "queued"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// Once the queue is idle, a drop is reported on its own.
+	c.dropped.Add(1)
+	c.dropSignal <- struct{}{}
+	select {
+	case data := <-frames:
+		if !strings.Contains(string(data), "slogx dropped 1 entries") {
+			t.Errorf("expected a drop notice, got %s", data)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected a drop on an idle queue to be reported")
+	}
+}
+
+func TestLogAck_CountsDeliveries(t *testing.T) {
+	s, srv := startTestServer(t)
+
+	if n := LogAck(INFO, "nobody listening"); n != 0 {
+		t.Errorf("expected 0 deliveries without clients, got %d", n)
+	}
+	args := make([]interface{}, 1, 2)
+	args[0] = "spare capacity"
+	LogAck(INFO, args...)
+	if spare := args[:2][1]; spare != nil {
+		t.Errorf("expected the caller's spare capacity untouched, got %#v", spare)
+	}
+
+	first := dialClient(t, wsURL(srv))
+	second := dialClient(t, wsURL(srv))
+	waitForClients(t, s, 2)
+
+	if n := LogAck(WARN, "audit event"); n != 2 {
+		t.Errorf("expected 2 deliveries, got %d", n)
+	}
+	for _, conn := range []*websocket.Conn{first, second} {
+		entry := readEntry(t, conn)
+		if entry.Args[0] != "audit event" || len(entry.Args) != 1 {
+			t.Errorf("expected the acked entry without the ack option, got %v", entry.Args)
+		}
+		if entry.Metadata["file"] != "server_test.go" {
+			t.Errorf("expected the caller's file, got %v", entry.Metadata["file"])
+		}
+	}
+
+	// Queued viewers count once they've actually been written to.
+	s.config.ClientQueueSize = 4
+	third := dialClient(t, wsURL(srv))
+	waitForClients(t, s, 3)
+	if n := LogAck(INFO, "queued audit"); n != 3 {
+		t.Errorf("expected 3 deliveries including the queued viewer, got %d", n)
+	}
+	if entry := readEntry(t, third); entry.Args[0] != "queued audit" {
+		t.Errorf("unexpected entry %v", entry.Args)
+	}
+}
+
+func TestLogCtx_StopsBroadcastOnCancel(t *testing.T) {
+	s, _ := startTestServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Whichever viewer is written to first cancels the request.
+	var received atomic.Int32
+	for _, name := range []string{"first", "second"} {
+		c := &client{
+			remoteAddr: name,
+			send: func(payload []byte) error {
+				received.Add(1)
+				cancel()
+				return nil
+			},
+			close: func() {},
+		}
+		s.addClient(c)
+		t.Cleanup(func() { s.removeClient(c) })
+	}
+
+	LogCtx(ctx, INFO, "request finished")
+
+	if n := received.Load(); n != 1 {
+		t.Errorf("expected only the first viewer to receive the entry, got %d", n)
+	}
+	if n := SkippedWrites(); n != 1 {
+		t.Errorf("expected 1 skipped write, got %d", n)
+	}
+
+	// Without a context every viewer still gets entries.
+	Info("unrelated")
+	if n := received.Load(); n != 3 {
+		t.Errorf("expected both viewers to receive a plain entry, got %d writes", n)
+	}
+}
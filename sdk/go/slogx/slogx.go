@@ -1,15 +1,19 @@
 package slogx
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
+	"io"
 	"math/rand"
 	"net"
 	"net/http"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -18,21 +22,295 @@ import (
 type LogLevel string
 
 const (
+	TRACE LogLevel = "TRACE"
 	DEBUG LogLevel = "DEBUG"
 	INFO  LogLevel = "INFO"
 	WARN  LogLevel = "WARN"
 	ERROR LogLevel = "ERROR"
 )
 
+// levelRank orders levels from most to least verbose.
+var levelRank = map[LogLevel]int{
+	TRACE: 0,
+	DEBUG: 1,
+	INFO:  2,
+	WARN:  3,
+	ERROR: 4,
+}
+
+// levelSeverity is the numeric severity sent with each entry, spaced so
+// levels can be added in between.
+var levelSeverity = map[LogLevel]int{
+	TRACE: 5,
+	DEBUG: 10,
+	INFO:  20,
+	WARN:  30,
+	ERROR: 40,
+}
+
+// ParseLevel converts a case-insensitive level name into a LogLevel.
+func ParseLevel(s string) (LogLevel, error) {
+	level := LogLevel(strings.ToUpper(strings.TrimSpace(s)))
+	if _, ok := levelRank[level]; !ok {
+		return "", fmt.Errorf("slogx: unknown log level %q", s)
+	}
+	return level, nil
+}
+
 type Config struct {
-	// IsDev is required. Must be true to enable slogx. Prevents accidental production use.
+	// IsDev enables the dev log server and CI mode. Without it only sinks
+	// that are configured explicitly (Console, LogFilePath, EnableServer)
+	// are active, which prevents accidental production use.
 	IsDev       bool
 	Port        int
 	ServiceName string
+	// ServiceNameFunc names the service for an entry logged with LogCtx,
+	// from its context, e.g. the tenant whose job is running. An empty
+	// result, or an entry logged without a context, gets the static name.
+	ServiceNameFunc func(ctx context.Context) string
+	// Version identifies the build in the handshake, e.g. a release tag.
+	// Defaults to the module version or VCS revision from the build info.
+	// VersionInEntries also adds it to every entry's metadata.
+	Version          string
+	VersionInEntries bool
 	// CIMode: undefined/nil (auto), true (force file), false (force ws)
 	CIMode      *bool
 	LogFilePath string
 	MaxEntries  int
+	// FlushInterval is the longest an entry waits in the log file's buffer
+	// before being written (default 500ms), however slowly entries arrive.
+	FlushInterval time.Duration
+	// FileMinLevel keeps entries below this level out of the log file
+	// while other sinks still get them, e.g. WARN to keep only problems on
+	// disk. Unset, the file gets every entry that passes MinLevel.
+	FileMinLevel LogLevel
+	// StackForErrorsOnly attaches a stacktrace only to ERROR entries and
+	// entries that log an error; others keep just file/line/func metadata.
+	StackForErrorsOnly bool
+	// StructuredStack adds the call stack as a `stackFrames` array of
+	// {function, file, line} objects next to the stacktrace string, so
+	// viewers can render it without parsing. It follows the same rules as
+	// the string, e.g. StackForErrorsOnly.
+	StructuredStack bool
+	// MaxStackFrames caps the frames kept in an entry's stack (default 32);
+	// the rest are summarized as a final "… N more frames" line, keeping
+	// logging from deep recursion cheap.
+	MaxStackFrames int
+	// MetadataPrefix is prepended to every metadata key slogx sets (e.g.
+	// "slogx_" turns `file` into `slogx_file`), avoiding collisions with
+	// fields of a downstream log pipeline.
+	MetadataPrefix string
+	// MinLevel drops entries below this level. Defaults to DEBUG, so TRACE
+	// entries are only emitted when explicitly requested.
+	MinLevel LogLevel
+	// DedupRefs emits pointers shared within one entry once, tagged with
+	// `$id`, and replaces later occurrences with `{"$ref": id}`.
+	DedupRefs bool
+	// NoServer skips starting the built-in listener. Mount Handler() on an
+	// existing server instead.
+	NoServer bool
+	// Listener serves the log server on an already-bound listener (e.g. from
+	// socket activation) instead of binding Port.
+	Listener net.Listener
+	// EscapeControlChars rewrites control characters in strings into visible
+	// escapes (e.g. a newline becomes `\n`).
+	EscapeControlChars bool
+	// RedactPaths replaces values at matching dotted paths with "[redacted]".
+	// Paths are relative to each logged arg; `*` matches any single segment,
+	// e.g. "user.apiToken" or "*.password".
+	RedactPaths []string
+	// HashPaths replaces values at matching dotted paths, given as in
+	// RedactPaths, with a short hash salted per process, e.g.
+	// "[hash:3f9a1c2b7e4d]". Equal values hash alike within a run, so they
+	// can be correlated without being logged. A `slogx:"hash"` struct tag
+	// does the same for a field.
+	HashPaths []string
+	// MaxStringLen truncates longer strings, marking how much was cut.
+	// 0 disables truncation.
+	MaxStringLen int
+	// BigIntsAsStrings logs integers beyond ±(2^53-1) as decimal strings,
+	// since browser viewers parse numbers as float64 and would silently
+	// round them. Smaller integers stay numbers.
+	BigIntsAsStrings bool
+	// MaxArgs caps the args kept per entry, replacing the rest with a
+	// "[N more args]" marker so an accidental Info(items...) stays readable.
+	// 0 disables the cap.
+	MaxArgs int
+	// BytesAsString logs a []byte holding printable UTF-8 text (tabs and
+	// newlines allowed) as a string, and any other []byte as a base64
+	// string. Off, byte slices are logged as arrays of numbers.
+	BytesAsString bool
+	// MaxDepth caps how deeply values are followed (default 128). Pointer
+	// hops count as well as maps, structs and slices, so long linked lists
+	// are cut off too. Deeper values are logged as "[max depth exceeded]".
+	MaxDepth int
+	// MaxObjectKeys caps the keys kept per map or struct (default 1000);
+	// the rest are summarized. MaxKeyLen caps each map key (default 256).
+	MaxObjectKeys int
+	MaxKeyLen     int
+	// MapEntriesOnCollision logs a map whose distinct keys stringify alike
+	// as a `[{"key": k, "value": v}]` list instead of suffixing the later
+	// keys with `#2`, `#3`, .... Either way the entry's metadata counts the
+	// affected maps in `keyCollisions`.
+	MapEntriesOnCollision bool
+	// UnexportedAllowlist restricts logging of unexported struct fields to
+	// these struct types; other structs log only exported fields. When empty,
+	// unexported fields are logged for every type.
+	UnexportedAllowlist []reflect.Type
+	// OmitZero leaves struct fields holding their type's zero value out of
+	// the logged object, like `omitempty` on every field. A struct field
+	// counts as zero only when all of its own fields are.
+	OmitZero bool
+	// ClassifyArgs splits each entry's args by role: the first string arg
+	// becomes `message`, logged errors go to `errors`, and everything else
+	// to `data`, so viewers don't have to guess. `args` is then left empty
+	// unless KeepFlatArgs is set for viewers that only read it.
+	ClassifyArgs bool
+	KeepFlatArgs bool
+	// ContextKeys names the context values to include when a
+	// context.Context is logged, e.g. {"requestID": requestIDKey{}}. Contexts
+	// are otherwise summarized by deadline and done state only.
+	ContextKeys map[string]interface{}
+	// SummarizePackages renders values whose type comes from one of these
+	// package paths as a `<Type>` summary instead of reflecting into their
+	// internals, e.g. "net/http" or "google.golang.org/grpc/...".
+	SummarizePackages []string
+	// RedactHeaders lists the headers, matched case-insensitively, whose
+	// values are logged as "[redacted]" when an *http.Request or
+	// *http.Response is logged. nil means Authorization,
+	// Proxy-Authorization, Cookie, Set-Cookie and X-Api-Key; an empty list
+	// shows every header.
+	RedactHeaders []string
+	// Console mirrors every entry to this writer (e.g. os.Stdout) in
+	// ConsoleFormat, JSON by default.
+	Console       io.Writer
+	ConsoleFormat ConsoleFormat
+	// ConsoleMinLevel is FileMinLevel for Console. Viewers choose their own
+	// level in their handshake reply.
+	ConsoleMinLevel LogLevel
+	// Format is the JSON shape of Console and log file entries: FormatNative
+	// (default) or FormatOTEL. Viewers always get the native shape.
+	Format Format
+	// OnClientConnect and OnClientDisconnect are called with the viewer's
+	// remote address. They run on their own goroutine.
+	OnClientConnect    func(remoteAddr string)
+	OnClientDisconnect func(remoteAddr string)
+	// OnEntry sees every entry before it reaches any sink. It may modify the
+	// entry; returning false drops it. The entry's maps and slices are
+	// reused after the call returns, so the hook must not keep them. A
+	// panicking hook keeps the entry unchanged from that point and records
+	// the panic in metadata as `hookPanic`.
+	OnEntry func(entry *LogEntry) (keep bool)
+	// CollapseRepeats suppresses an entry identical to the one logged just
+	// before it. Entries are identical when level, args, stack and metadata
+	// all match; id, seq and timestamps are ignored. When a different entry
+	// arrives (or on Shutdown), an entry reading "(repeated xN)" with
+	// metadata `repeated` and `repeatOf` (the first entry's id) is emitted
+	// first.
+	CollapseRepeats bool
+	// DisableHTMLEscape leaves <, > and & unescaped in streamed JSON.
+	DisableHTMLEscape bool
+	// JSONIndent pretty-prints streamed JSON with this indent (e.g. "  ").
+	JSONIndent string
+	// PrettyJSON indents entries written to the log file and, in
+	// ConsoleJSON format, the console, for reading by hand. Entries are
+	// then separated by a blank line rather than one per line, so the file
+	// is no longer NDJSON; leave it off for files a viewer loads. Viewer
+	// streams follow JSONIndent instead, so they stay compact by default.
+	PrettyJSON bool
+	// EnableViewer serves a minimal built-in log viewer at `/viewer`.
+	EnableViewer bool
+	// EnableServer: undefined/nil (follow IsDev), true (start the log server
+	// even outside dev), false (never start it)
+	EnableServer *bool
+	// WriteTimeout bounds each write to a viewer (default 5s). A client
+	// whose write fails or times out is disconnected.
+	WriteTimeout time.Duration
+	// MaxConnectionAge closes viewer connections once they have been open
+	// this long, with a normal close code, so viewers reconnect with fresh
+	// state. 0 keeps connections open indefinitely.
+	MaxConnectionAge time.Duration
+	// MaxMessageSize caps the size of a frame a WebSocket viewer may send
+	// (default 4KiB); viewers only send small preference messages. A larger
+	// frame closes the connection.
+	MaxMessageSize int64
+	// ClientQueueSize gives each viewer a send queue of this many entries so
+	// slow viewers don't block logging. Entries that don't fit are dropped,
+	// and the viewer is sent a "slogx dropped N entries" system entry. 0
+	// writes to viewers synchronously.
+	ClientQueueSize int
+	// ReplayBuffer keeps this many recent entries so a viewer reconnecting
+	// with `?lastSeq=N` receives the entries it missed. 0 disables it.
+	ReplayBuffer int
+	// FullPayloadSampleRate, between 0 and 1, keeps the full payload on
+	// that fraction of entries; the rest keep only their message, with the
+	// payload's size in metadata as `omittedBytes`. ERROR entries are
+	// always kept whole. 0 (or 1) keeps every payload.
+	FullPayloadSampleRate float64
+	// BlobTTL is how long data kept by Blob stays available at /blob/{id}
+	// (default 10m).
+	BlobTTL time.Duration
+	// MaxBlobs caps how many blobs are kept (default 64); the least
+	// recently used is dropped first.
+	MaxBlobs int
+	// BurstSize is how many entries a BeginBurst scope holds back while
+	// waiting for an error (default 256); older ones are discarded first.
+	BurstSize int
+	// WireFormat is how entries are encoded for WebSocket viewers that don't
+	// ask for a format in their handshake reply: WireJSON (default) or
+	// WireMsgPack.
+	WireFormat WireFormat
+}
+
+// serverEnabled reports whether the log server may run under this config.
+func (c *Config) serverEnabled() bool {
+	if c.EnableServer != nil {
+		return *c.EnableServer
+	}
+	return c.IsDev
+}
+
+const defaultWriteTimeout = 5 * time.Second
+
+const defaultMaxMessageSize = 4 << 10
+
+const defaultMaxStackFrames = 32
+
+func (c *Config) maxStackFrames() int {
+	if c.MaxStackFrames > 0 {
+		return c.MaxStackFrames
+	}
+	return defaultMaxStackFrames
+}
+
+func (c *Config) wireFormat() WireFormat {
+	if validWireFormat(c.WireFormat) {
+		return c.WireFormat
+	}
+	return WireJSON
+}
+
+const defaultBurstSize = 256
+
+func (c *Config) burstSize() int {
+	if c.BurstSize > 0 {
+		return c.BurstSize
+	}
+	return defaultBurstSize
+}
+
+func (c *Config) maxMessageSize() int64 {
+	if c.MaxMessageSize > 0 {
+		return c.MaxMessageSize
+	}
+	return defaultMaxMessageSize
+}
+
+func (c *Config) writeTimeout() time.Duration {
+	if c.WriteTimeout > 0 {
+		return c.WriteTimeout
+	}
+	return defaultWriteTimeout
 }
 
 // Detect if running in a CI environment
@@ -56,111 +334,252 @@ func isCI() bool {
 }
 
 type LogEntry struct {
-	ID         string                 `json:"id"`
-	Timestamp  string                 `json:"timestamp"`
-	Level      LogLevel               `json:"level"`
-	Args       []interface{}          `json:"args"`
-	Stacktrace string                 `json:"stacktrace,omitempty"`
-	Metadata   map[string]interface{} `json:"metadata"`
+	ID        string        `json:"id"`
+	Seq       uint64        `json:"seq"`
+	Timestamp string        `json:"timestamp"`
+	Level     LogLevel      `json:"level"`
+	Severity  int           `json:"severity"`            // Level as a number, for sorting and filtering
+	ParentID  string        `json:"parentId,omitempty"`  // previous entry in the same WithChain context
+	Component string        `json:"component,omitempty"` // set with the Component option
+	Args      []interface{} `json:"args"`
+	// Message, Data and Errors hold the args split by role when
+	// Config.ClassifyArgs is set.
+	Message     string                 `json:"message,omitempty"`
+	Data        []interface{}          `json:"data,omitempty"`
+	Errors      []interface{}          `json:"errors,omitempty"`
+	Stacktrace  string                 `json:"stacktrace,omitempty"`
+	StackFrames []StackFrame           `json:"stackFrames,omitempty"`
+	Metadata    map[string]interface{} `json:"metadata"`
+}
+
+// StackFrame is one call in an entry's structured stack, innermost first.
+type StackFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
 }
 
 type SlogX struct {
-	clients     map[*websocket.Conn]bool
+	config      Config
+	clients     map[*client]bool
 	clientsMu   sync.RWMutex
-	serviceName string
+	serviceName atomic.Value // string; see SetServiceName
+	minLevel    LogLevel
 	upgrader    websocket.Upgrader
 	ciWriter    *CIWriter
+	server      *http.Server
+	consoleMu   sync.Mutex
+	// seq numbers entries in call order so viewers can detect gaps.
+	seq atomic.Uint64
+	// startedAt is when the instance was created, reported in the handshake.
+	startedAt time.Time
+	// version is the application version reported to viewers.
+	version string
+	// replay holds recent frames for reconnecting viewers; nil when
+	// Config.ReplayBuffer is 0.
+	replay *replayBuffer
+	// clientJoined is closed and replaced whenever a client connects.
+	clientJoined chan struct{}
+	// repeats tracks the current run of identical entries for
+	// Config.CollapseRepeats.
+	repeats repeatState
+	// recorders receive a copy of every broadcast frame; see Record.
+	recordMu  sync.Mutex
+	recorders map[*recorder]bool
+	// skipped counts viewer writes abandoned because the entry's context
+	// was done; see LogCtx.
+	skipped atomic.Uint64
+	// trimmed counts entries whose payload was dropped by
+	// Config.FullPayloadSampleRate.
+	trimmed atomic.Uint64
+	// captures receive a copy of every emitted entry; see Capture.
+	captureMu sync.Mutex
+	captures  map[*Captured]bool
+	// blobs holds the data behind logged BlobRefs.
+	blobs blobCache
 }
 
+// defaultServiceName is reported until a service name is configured.
+const defaultServiceName = "go-service"
+
 var instance *SlogX
 var once sync.Once
 
 func getInstance() *SlogX {
 	once.Do(func() {
 		instance = &SlogX{
-			clients:     make(map[*websocket.Conn]bool),
-			serviceName: "go-service",
+			clients:      make(map[*client]bool),
+			clientJoined: make(chan struct{}),
+			minLevel:     DEBUG,
+			startedAt:    time.Now().UTC(),
+			version:      buildVersion(),
 			upgrader: websocket.Upgrader{
 				CheckOrigin: func(r *http.Request) bool { return true },
 			},
 		}
+		instance.serviceName.Store(defaultServiceName)
 	})
 	return instance
 }
 
 func Init(config Config) {
-	if !config.IsDev {
+	if _, _, err := InitReady(config); err != nil {
+		panic(err)
+	}
+}
+
+// InitReady is Init for callers that need to know when and where the log
+// server accepts connections, e.g. tests. It returns the server's address
+// and a channel closed once it is serving, or the error that kept it from
+// binding. Without a server, addr is empty and ready is already closed.
+func InitReady(config Config) (addr string, ready <-chan struct{}, err error) {
+	serving := make(chan struct{})
+	noServer := func() (string, <-chan struct{}, error) {
+		close(serving)
+		return "", serving, nil
+	}
+
+	if !config.IsDev && !config.serverEnabled() && config.Console == nil && config.LogFilePath == "" {
 		// Silently skip initialization in production
-		return
+		return noServer()
 	}
 
 	s := getInstance()
+	s.config = config
 
 	if config.ServiceName != "" {
-		s.serviceName = config.ServiceName
+		s.serviceName.Store(config.ServiceName)
+	}
+
+	if _, ok := levelRank[config.MinLevel]; ok {
+		s.minLevel = config.MinLevel
 	}
 
-	// Determine CI Mode
+	if config.Version != "" {
+		s.version = config.Version
+	}
+
+	// Determine CI Mode. Outside dev it is never auto-detected.
 	useCI := false
 	if config.CIMode != nil {
 		useCI = *config.CIMode
-	} else {
+	} else if config.IsDev {
 		useCI = isCI()
 	}
 
 	if useCI {
 		logPath := config.LogFilePath
 		if logPath == "" {
-			logPath = fmt.Sprintf("./slogx_logs/%s.ndjson", s.serviceName)
+			logPath = fmt.Sprintf("./slogx_logs/%s.ndjson", s.service())
 		}
 
-		s.ciWriter = NewCIWriter(logPath, config.MaxEntries)
+		s.ciWriter = newCIWriter(logPath, config.MaxEntries, config.FlushInterval, config.PrettyJSON)
 		fmt.Printf("[slogx] 📝 CI mode: logging to %s\n", logPath)
-		return
+		return noServer()
+	}
+
+	// Outside CI mode an explicit LogFilePath is an additional file sink.
+	if config.LogFilePath != "" {
+		s.ciWriter = newCIWriter(config.LogFilePath, config.MaxEntries, config.FlushInterval, config.PrettyJSON)
+	}
+
+	if !config.serverEnabled() {
+		return noServer()
 	}
 
-	port := config.Port
-	if port == 0 {
-		port = 8080
+	if config.ReplayBuffer > 0 {
+		s.replay = newReplayBuffer(config.ReplayBuffer)
 	}
 
-	mux := http.NewServeMux()
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		conn, err := s.upgrader.Upgrade(w, r, nil)
+	if config.NoServer {
+		return noServer()
+	}
+
+	listener := config.Listener
+	if listener == nil {
+		port := config.Port
+		if port == 0 {
+			port = 8080
+		}
+
+		// Create listener first so we know the server is ready
+		listener, err = net.Listen("tcp", fmt.Sprintf(":%d", port))
 		if err != nil {
-			return
+			return "", nil, fmt.Errorf("slogx: failed to bind to port %d: %w", port, err)
 		}
+		fmt.Printf("[slogx] 🚀 Log server running at ws://localhost:%d\n", port)
+	} else {
+		fmt.Printf("[slogx] 🚀 Log server running at ws://%s\n", listener.Addr())
+	}
 
-		s.clientsMu.Lock()
-		s.clients[conn] = true
-		s.clientsMu.Unlock()
-
-		go func() {
-			defer func() {
-				s.clientsMu.Lock()
-				delete(s.clients, conn)
-				s.clientsMu.Unlock()
-				conn.Close()
-			}()
-			for {
-				if _, _, err := conn.ReadMessage(); err != nil {
-					break
-				}
-			}
-		}()
-	})
+	s.server = &http.Server{
+		Handler: s.handler(),
+		// Serve calls BaseContext once, right before it starts accepting.
+		BaseContext: func(net.Listener) context.Context {
+			close(serving)
+			return context.Background()
+		},
+	}
+	go s.server.Serve(listener)
+	return listener.Addr().String(), serving, nil
+}
 
-	// Create listener first so we know the server is ready
-	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
-	if err != nil {
-		panic(fmt.Sprintf("[slogx] Failed to bind to port %d: %v", port, err))
+// metaKey returns the metadata key for a built-in field.
+func (s *SlogX) metaKey(name string) string {
+	return s.config.MetadataPrefix + name
+}
+
+// service returns the service name reported in entries.
+func (s *SlogX) service() string {
+	return s.serviceName.Load().(string)
+}
+
+// entryService is the service reported on an entry logged with ctx, which
+// is nil outside LogCtx; see Config.ServiceNameFunc.
+func (s *SlogX) entryService(ctx context.Context) string {
+	if fn := s.config.ServiceNameFunc; fn != nil && ctx != nil {
+		if name := fn(ctx); name != "" {
+			return name
+		}
 	}
+	return s.service()
+}
 
-	fmt.Printf("[slogx] 🚀 Log server running at ws://localhost:%d\n", port)
+// SetServiceName changes the service name reported by subsequent entries,
+// without reinitializing. An empty name restores the default.
+func SetServiceName(name string) {
+	if name == "" {
+		name = defaultServiceName
+	}
+	getInstance().serviceName.Store(name)
+}
 
-	go func() {
-		http.Serve(listener, mux)
-	}()
+// ServiceName returns the service name currently reported in entries.
+func ServiceName() string {
+	return getInstance().service()
+}
+
+// Shutdown stops the log server, disconnects all clients, and flushes the
+// CI log file. It is safe to call when slogx was never initialized.
+func Shutdown(ctx context.Context) error {
+	s := getInstance()
+	s.flushRepeats()
+
+	// Disconnect clients first: hijacked WebSocket connections and streaming
+	// SSE requests would otherwise keep the server from shutting down.
+	s.closeClients()
+
+	var err error
+	if s.server != nil {
+		err = s.server.Shutdown(ctx)
+		s.server = nil
+	}
+
+	if s.ciWriter != nil {
+		s.ciWriter.Close()
+		s.ciWriter = nil
+	}
+	return err
 }
 
 func generateID() string {
@@ -172,16 +591,39 @@ func generateID() string {
 	return string(b)
 }
 
-func getCallerInfo() (file string, line int, funcName string, stack string) {
-	pc := make([]uintptr, 10)
-	n := runtime.Callers(5, pc)
-	frames := runtime.CallersFrames(pc[:n])
+// getCallerInfo reports the frame skip levels above the code that called a
+// public logging function. The stack keeps at most maxFrames frames and
+// ends with a "… N more frames" marker when cut; it is also returned as
+// frames when structured is set.
+func getCallerInfo(skip, maxFrames int, structured bool) (file string, line int, funcName string, stack string, stackFrames []StackFrame) {
+	// Skip runtime.Callers, getCallerInfo, log, and the public entry point.
+	// Grow the buffer until the whole stack fits so the cut-off frames can be
+	// counted; program counters are cheap next to formatting frames.
+	pc := make([]uintptr, maxFrames+1)
+	for {
+		n := runtime.Callers(4+skip, pc)
+		if n < len(pc) {
+			pc = pc[:n]
+			break
+		}
+		pc = make([]uintptr, 2*len(pc))
+	}
+	frames := runtime.CallersFrames(pc)
 
 	var stackLines string
 	first := true
+	recorded, omitted := 0, 0
 	for {
 		frame, more := frames.Next()
-		stackLines += fmt.Sprintf("at %s (%s:%d)\n", frame.Function, frame.File, frame.Line)
+		if recorded == maxFrames {
+			omitted++
+		} else {
+			recorded++
+			stackLines += fmt.Sprintf("at %s (%s:%d)\n", frame.Function, frame.File, frame.Line)
+			if structured {
+				stackFrames = append(stackFrames, StackFrame{Function: frame.Function, File: frame.File, Line: frame.Line})
+			}
+		}
 		if first {
 			file = filepath.Base(frame.File)
 			line = frame.Line
@@ -192,74 +634,356 @@ func getCallerInfo() (file string, line int, funcName string, stack string) {
 			break
 		}
 	}
-	return file, line, funcName, stackLines
+	if omitted > 0 {
+		marker := fmt.Sprintf("… %d more frames", omitted)
+		stackLines += marker + "\n"
+		if structured {
+			stackFrames = append(stackFrames, StackFrame{Function: marker})
+		}
+	}
+	return file, line, funcName, stackLines, stackFrames
+}
+
+// atLeast reports whether level passes a sink's minimum level. An unset
+// minimum passes every level.
+func atLeast(level, min LogLevel) bool {
+	return levelRank[level] >= levelRank[min]
+}
+
+// enabled reports whether an entry at level passes the level filter: the
+// WithLevel override of a LogCtx context when args carry one, otherwise
+// MinLevel.
+func (s *SlogX) enabled(level LogLevel, args []interface{}) bool {
+	minLevel := s.minLevel
+	if scoped, ok := scopedLevel(args); ok {
+		minLevel = scoped
+	}
+	return atLeast(level, minLevel)
 }
 
-func log(level LogLevel, args ...interface{}) {
+// log builds and emits an entry, returning how many clients it was written
+// to.
+func log(skip int, level LogLevel, args ...interface{}) (delivered int) {
 	s := getInstance()
 
+	if !s.enabled(level, args) {
+		return 0
+	}
+
 	s.clientsMu.RLock()
 	hasClients := len(s.clients) > 0
 	s.clientsMu.RUnlock()
 
-	if s.ciWriter == nil && !hasClients {
-		return
+	if s.ciWriter == nil && !hasClients && s.config.Console == nil && s.replay == nil && !s.recording() && !s.capturing() {
+		return 0
 	}
 
-	file, line, funcName, stack := getCallerInfo()
+	// Logging must never take down the caller.
+	defer s.recoverLog(level)
+
+	file, line, funcName, stack, stackFrames := getCallerInfo(skip, s.config.maxStackFrames(), s.config.StructuredStack)
+	opts, args := splitOptions(args)
+	if max := s.config.MaxArgs; max > 0 && len(args) > max {
+		// Copy rather than overwrite the caller's slice when adding the marker.
+		args = append(args[:max:max], fmt.Sprintf("[%d more args]", len(args)-max))
+	}
 
-	processedArgs := make([]interface{}, len(args))
+	processedArgs := getArgs(len(args))
+	defer putArgs(processedArgs)
 	finalStack := stack
+	if s.config.StackForErrorsOnly && level != ERROR {
+		finalStack = ""
+	}
+	ser := newSerializer(&s.config)
 
 	for i, arg := range args {
-		if err, ok := arg.(error); ok {
-			finalStack = fmt.Sprintf("%v\n%s", err, stack)
-			processedArgs[i] = map[string]interface{}{
-				"name":    "Error",
-				"message": err.Error(),
-				"stack":   finalStack,
-			}
+		if err, ok := arg.(error); ok && !isNilValue(err) {
+			processedArgs[i], finalStack = ser.serializeError(err, stack)
 		} else {
-			processedArgs[i] = Serialize(arg)
+			processedArgs[i] = ser.serialize(arg)
 		}
 	}
+	ser.finish()
+
+	metadata := getMetadata()
+	defer putMetadata(metadata)
+	metadata[s.metaKey("file")] = file
+	metadata[s.metaKey("line")] = line
+	metadata[s.metaKey("func")] = funcName
+	metadata[s.metaKey("lang")] = "go"
+	metadata[s.metaKey("service")] = s.entryService(opts.ctx)
+	if s.config.VersionInEntries && s.version != "" {
+		metadata[s.metaKey("version")] = s.version
+	}
+	if ser.keyCollisions > 0 {
+		// Distinct map keys were logged under suffixed or entry-list form.
+		metadata[s.metaKey("keyCollisions")] = ser.keyCollisions
+	}
 
+	now := time.Now().UTC()
 	entry := LogEntry{
 		ID:         generateID(),
-		Timestamp:  time.Now().UTC().Format(time.RFC3339Nano),
+		Timestamp:  now.Format(time.RFC3339Nano),
 		Level:      level,
+		Severity:   levelSeverity[level],
+		Component:  opts.component,
 		Args:       processedArgs,
 		Stacktrace: finalStack,
+		Metadata:   metadata,
+	}
+	if finalStack != "" {
+		entry.StackFrames = stackFrames
+	}
+
+	if s.config.ClassifyArgs {
+		entry.Message, entry.Data, entry.Errors = classifyArgs(args, processedArgs)
+		if !s.config.KeepFlatArgs {
+			entry.Args = processedArgs[:0]
+		}
+	}
+
+	if !opts.timestamp.IsZero() {
+		entry.Timestamp = opts.timestamp.UTC().Format(time.RFC3339Nano)
+		entry.Metadata[s.metaKey("ingestedAt")] = now.Format(time.RFC3339Nano)
+	}
+
+	if !s.runOnEntry(&entry) {
+		return 0
+	}
+	// The hook may have changed the level.
+	entry.Severity = levelSeverity[entry.Level]
+	s.samplePayload(&entry)
+	opts.chain = chainFrom(opts.ctx)
+
+	if b := burstFrom(opts.ctx); b != nil {
+		if levelRank[entry.Level] < levelRank[ERROR] && b.hold(entry) {
+			return 0
+		}
+		for _, held := range b.take() {
+			s.deliver(held, entryOptions{chain: opts.chain})
+		}
+	}
+	return s.deliver(entry, opts)
+}
+
+// deliver numbers a kept entry and sends it to every sink.
+func (s *SlogX) deliver(entry LogEntry, opts entryOptions) (delivered int) {
+	if s.config.CollapseRepeats {
+		return s.emitCollapsed(entry, opts)
+	}
+	// Numbered once kept, so dropped entries don't look like gaps.
+	entry.Seq = s.seq.Add(1)
+	opts.chain.linkTo(&entry)
+	return s.emit(entry, opts)
+}
+
+// isNilValue reports whether v holds a nil pointer or other nil reference,
+// e.g. a typed nil error, whose methods would likely panic.
+func isNilValue(v interface{}) bool {
+	val := reflect.ValueOf(v)
+	switch val.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan, reflect.Interface:
+		return val.IsNil()
+	}
+	return false
+}
+
+// classifyArgs sorts serialized args by role for Config.ClassifyArgs,
+// using the original args to tell strings and errors apart.
+func classifyArgs(args, processed []interface{}) (message string, data, errs []interface{}) {
+	hasMessage := false
+	for i, arg := range args {
+		switch arg.(type) {
+		case error:
+			if !isNilValue(arg) {
+				errs = append(errs, processed[i])
+				continue
+			}
+		case string:
+			if !hasMessage {
+				message, hasMessage = processed[i].(string)
+				if hasMessage {
+					continue
+				}
+			}
+		}
+		data = append(data, processed[i])
+	}
+	return message, data, errs
+}
+
+// runOnEntry applies Config.OnEntry, reporting whether to keep the entry.
+func (s *SlogX) runOnEntry(entry *LogEntry) (keep bool) {
+	hook := s.config.OnEntry
+	if hook == nil {
+		return true
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			if entry.Metadata == nil {
+				entry.Metadata = make(map[string]interface{})
+			}
+			entry.Metadata[s.metaKey("hookPanic")] = fmt.Sprint(r)
+			keep = true
+		}
+	}()
+	return hook(entry)
+}
+
+// emit delivers a finished entry to every active sink: the console mirror,
+// the log file, captures, and connected clients. It returns how many
+// clients the entry was written to; see broadcast for what ack changes.
+func (s *SlogX) emit(entry LogEntry, opts entryOptions) (delivered int) {
+	if atLeast(entry.Level, s.config.ConsoleMinLevel) {
+		s.writeConsole(entry)
+	}
+
+	if s.ciWriter != nil && atLeast(entry.Level, s.config.FileMinLevel) {
+		s.ciWriter.Write(s.formatted(entry))
+	}
+	s.capture(entry)
+
+	s.clientsMu.RLock()
+	hasClients := len(s.clients) > 0
+	s.clientsMu.RUnlock()
+	recording := s.recording()
+	if !hasClients && s.replay == nil && !recording {
+		return 0
+	}
+
+	// Broadcast to WebSocket and SSE clients. The frame is buffered first so
+	// a viewer resuming concurrently either replays it or receives it live.
+	marshalPooled(entry, &s.config, func(payload []byte) {
+		if recording {
+			s.record(payload)
+		}
+		if s.replay != nil {
+			s.replay.add(entry.Seq, payload)
+		}
+		if hasClients {
+			delivered = s.broadcast(&entry, payload, opts)
+		}
+	})
+	return delivered
+}
+
+// recoverLog swallows a panic raised while building or delivering an entry
+// and makes a best-effort attempt to emit a minimal fallback entry instead.
+func (s *SlogX) recoverLog(level LogLevel) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	// The fallback itself must not panic either.
+	defer func() { recover() }()
+
+	s.emit(LogEntry{
+		ID:        generateID(),
+		Seq:       s.seq.Add(1),
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+		Level:     level,
+		Severity:  levelSeverity[level],
+		Args:      []interface{}{"internal slogx error"},
 		Metadata: map[string]interface{}{
-			"file":    file,
-			"line":    line,
-			"func":    funcName,
-			"lang":    "go",
-			"service": s.serviceName,
+			s.metaKey("lang"):    "go",
+			s.metaKey("service"): s.service(),
+			s.metaKey("panic"):   fmt.Sprint(r),
 		},
+	}, entryOptions{})
+}
+
+// Log emits an entry at a level chosen at runtime. Unknown levels are
+// logged as INFO rather than dropped.
+func Log(level LogLevel, args ...interface{}) {
+	if _, ok := levelRank[level]; !ok {
+		level = INFO
 	}
+	log(0, level, args...)
+}
 
-	// CI Mode: Write to file
-	if s.ciWriter != nil {
-		s.ciWriter.Write(entry)
-		return
+// LogSkip is Log for logging wrappers: skip is the number of wrapper frames
+// between the real caller and LogSkip, so a helper that calls LogSkip
+// directly passes 1 and its entries report the helper's caller.
+func LogSkip(skip int, level LogLevel, args ...interface{}) {
+	if _, ok := levelRank[level]; !ok {
+		level = INFO
+	}
+	if skip < 0 {
+		skip = 0
 	}
+	log(skip, level, args...)
+}
 
-	// WebSocket Mode: Broadcast
-	payload, err := json.Marshal(entry)
-	if err != nil {
-		return
+// LogAck is Log for entries whose delivery matters: it returns how many
+// viewers the entry was written to, waiting for queued viewers (see
+// ClientQueueSize) to write it. Console and file sinks aren't counted.
+func LogAck(level LogLevel, args ...interface{}) (delivered int) {
+	return LogAckSkip(1, level, args...)
+}
+
+// LogAckSkip is LogAck for logging wrappers; skip works as in LogSkip.
+func LogAckSkip(skip int, level LogLevel, args ...interface{}) (delivered int) {
+	if _, ok := levelRank[level]; !ok {
+		level = INFO
+	}
+	if skip < 0 {
+		skip = 0
 	}
+	// Cap the capacity so the option never lands in the caller's array.
+	return log(skip, level, append(args[:len(args):len(args)], ackOption{})...)
+}
 
-	s.clientsMu.RLock()
-	defer s.clientsMu.RUnlock()
+// LogCtx is Log for request-scoped code: once ctx is done, the entry is no
+// longer written to the viewers not yet reached, so a cancelled request
+// doesn't wait on slow viewers. Skipped writes are counted in
+// SkippedWrites. ctx is not logged; pass it as an arg too to log it.
+func LogCtx(ctx context.Context, level LogLevel, args ...interface{}) {
+	LogCtxSkip(ctx, 1, level, args...)
+}
 
-	for conn := range s.clients {
-		conn.WriteMessage(websocket.TextMessage, payload)
+// LogCtxSkip is LogCtx for logging wrappers; skip works as in LogSkip.
+func LogCtxSkip(ctx context.Context, skip int, level LogLevel, args ...interface{}) {
+	if _, ok := levelRank[level]; !ok {
+		level = INFO
+	}
+	if skip < 0 {
+		skip = 0
+	}
+	// Cap the capacity so the option never lands in the caller's array.
+	log(skip, level, append(args[:len(args):len(args)], ctxOption{ctx})...)
+}
+
+// SkippedWrites reports how many viewer writes were skipped because the
+// context passed to LogCtx was done.
+func SkippedWrites() uint64 {
+	return getInstance().skipped.Load()
+}
+
+// Msg logs a message with structured fields, merging the field maps into a
+// single object (later maps win on duplicate keys), e.g.
+// Msg(INFO, "request done", map[string]interface{}{"status": 200}).
+func Msg(level LogLevel, msg string, fields ...map[string]interface{}) {
+	MsgSkip(1, level, msg, fields...)
+}
+
+// MsgSkip is Msg for logging wrappers; skip works as in LogSkip.
+func MsgSkip(skip int, level LogLevel, msg string, fields ...map[string]interface{}) {
+	if len(fields) == 0 {
+		LogSkip(skip+1, level, msg)
+		return
+	}
+	merged := make(map[string]interface{})
+	for _, f := range fields {
+		for k, v := range f {
+			merged[k] = v
+		}
 	}
+	LogSkip(skip+1, level, msg, merged)
 }
 
-func Debug(args ...interface{}) { log(DEBUG, args...) }
-func Info(args ...interface{})  { log(INFO, args...) }
-func Warn(args ...interface{})  { log(WARN, args...) }
-func Error(args ...interface{}) { log(ERROR, args...) }
+func Trace(args ...interface{}) { log(0, TRACE, args...) }
+func Debug(args ...interface{}) { log(0, DEBUG, args...) }
+func Info(args ...interface{})  { log(0, INFO, args...) }
+func Warn(args ...interface{})  { log(0, WARN, args...) }
+func Error(args ...interface{}) { log(0, ERROR, args...) }
@@ -0,0 +1,943 @@
+package slogx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// resetInstance tears down the singleton so each test starts from a clean Init.
+func resetInstance() {
+	if instance != nil {
+		Shutdown(context.Background())
+	}
+	instance = nil
+	once = sync.Once{}
+}
+
+// initCapture initializes slogx in CI mode against a temp file and returns a
+// function that flushes and reads back every entry written so far.
+func initCapture(t *testing.T, config Config) func() []LogEntry {
+	t.Helper()
+	resetInstance()
+	t.Cleanup(resetInstance)
+
+	filePath := filepath.Join(t.TempDir(), "entries.ndjson")
+	ciMode := true
+	config.IsDev = true
+	config.CIMode = &ciMode
+	config.LogFilePath = filePath
+	Init(config)
+
+	return func() []LogEntry {
+		t.Helper()
+		getInstance().ciWriter.Flush()
+
+		content, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var entries []LogEntry
+		for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+			if line == "" {
+				continue
+			}
+			var entry LogEntry
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				t.Fatal(err)
+			}
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected LogLevel
+	}{
+		{"trace", TRACE},
+		{"DEBUG", DEBUG},
+		{" Info ", INFO},
+		{"warn", WARN},
+		{"Error", ERROR},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			level, err := ParseLevel(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if level != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, level)
+			}
+		})
+	}
+
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("expected error for unknown level")
+	}
+}
+
+func TestTrace_FilteredByDefault(t *testing.T) {
+	read := initCapture(t, Config{})
+
+	Trace("hidden")
+	Debug("visible")
+
+	entries := read()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	if entries[0].Level != DEBUG {
+		t.Errorf("expected DEBUG, got %s", entries[0].Level)
+	}
+}
+
+func TestTrace_EmittedAtTraceLevel(t *testing.T) {
+	read := initCapture(t, Config{MinLevel: TRACE})
+
+	Trace("very verbose")
+
+	entries := read()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	if entries[0].Level != TRACE {
+		t.Errorf("expected TRACE, got %s", entries[0].Level)
+	}
+}
+
+func TestMinLevel_FiltersLowerLevels(t *testing.T) {
+	read := initCapture(t, Config{MinLevel: WARN})
+
+	Trace("dropped")
+	Debug("dropped")
+	Info("dropped")
+	Warn("kept")
+	Error("kept")
+
+	entries := read()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Level != WARN || entries[1].Level != ERROR {
+		t.Errorf("expected WARN, ERROR; got %s, %s", entries[0].Level, entries[1].Level)
+	}
+}
+
+func TestSinkMinLevels_RouteByLevel(t *testing.T) {
+	var console bytes.Buffer
+	read := initCapture(t, Config{FileMinLevel: WARN, Console: &console, ConsoleMinLevel: ERROR})
+
+	var streamed []string
+	getInstance().addClient(&client{
+		send: func(payload []byte) error {
+			var entry LogEntry
+			json.Unmarshal(payload, &entry)
+			streamed = append(streamed, string(entry.Level))
+			return nil
+		},
+		close: func() {},
+	})
+
+	Debug("cache miss")
+	Info("request done")
+	Warn("slow query")
+	Error("upstream failed")
+
+	var filed []string
+	for _, entry := range read() {
+		filed = append(filed, string(entry.Level))
+	}
+	if strings.Join(filed, ",") != "WARN,ERROR" {
+		t.Errorf("expected WARN and up in the file, got %v", filed)
+	}
+	if strings.Join(streamed, ",") != "DEBUG,INFO,WARN,ERROR" {
+		t.Errorf("expected every level streamed to viewers, got %v", streamed)
+	}
+	if lines := strings.Count(console.String(), "\n"); lines != 1 || !strings.Contains(console.String(), "upstream failed") {
+		t.Errorf("expected only the ERROR on the console, got %q", console.String())
+	}
+}
+
+func TestDedupRefs_SharedAcrossArgs(t *testing.T) {
+	read := initCapture(t, Config{DedupRefs: true})
+
+	session := &mixedStruct{Public: "session"}
+	Info("users", &sharedSessionUser{Name: "a", Session: session}, &sharedSessionUser{Name: "b", Session: session})
+
+	entries := read()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+
+	first := entries[0].Args[1].(map[string]interface{})["Session"].(map[string]interface{})
+	second := entries[0].Args[2].(map[string]interface{})["Session"].(map[string]interface{})
+	if first["Public"] != "session" || second["$ref"] != first["$id"] {
+		t.Errorf("expected second arg to reference first, got %v and %v", first, second)
+	}
+}
+
+func TestLog_SanitizesStrings(t *testing.T) {
+	read := initCapture(t, Config{EscapeControlChars: true})
+
+	Info(string([]byte{0xc3, 0x28}), "bell\x07\x1b[31m")
+
+	entries := read()
+	if len(entries) != 1 {
+		t.Fatalf("expected entry to be delivered, got %d entries", len(entries))
+	}
+	if entries[0].Args[0] != "�(" {
+		t.Errorf("expected invalid UTF-8 replaced, got %q", entries[0].Args[0])
+	}
+	if entries[0].Args[1] != `bell\x07\x1b[31m` {
+		t.Errorf("expected escaped control chars, got %q", entries[0].Args[1])
+	}
+}
+
+// panicMarshaler survives Serialize as a basic value and panics once the
+// entry is marshaled.
+type panicMarshaler int
+
+func (panicMarshaler) MarshalJSON() ([]byte, error) {
+	panic("boom")
+}
+
+func TestLog_RecoversFromPanic(t *testing.T) {
+	read := initCapture(t, Config{})
+
+	deep := map[string]interface{}{
+		"level1": map[string]interface{}{
+			"level2": []interface{}{"ok", panicMarshaler(1)},
+		},
+	}
+
+	// Reaching the assertions at all means the caller survived.
+	Error("about to panic", deep)
+	Info("still logging")
+
+	entries := read()
+	if len(entries) != 2 {
+		t.Fatalf("expected fallback and follow-up entries, got %d", len(entries))
+	}
+
+	fallback := entries[0]
+	if fallback.Level != ERROR {
+		t.Errorf("expected fallback to keep ERROR level, got %s", fallback.Level)
+	}
+	if len(fallback.Args) != 1 || fallback.Args[0] != "internal slogx error" {
+		t.Errorf("expected fallback message, got %v", fallback.Args)
+	}
+	if fallback.Metadata["panic"] != "boom" {
+		t.Errorf("expected panic value in metadata, got %v", fallback.Metadata["panic"])
+	}
+
+	if entries[1].Args[0] != "still logging" {
+		t.Errorf("expected follow-up entry, got %v", entries[1].Args)
+	}
+}
+
+func TestLog_PooledObjectsReset(t *testing.T) {
+	read := initCapture(t, Config{})
+
+	Info("first", "second", "third", At(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+	Info("only")
+
+	entries := read()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if len(entries[1].Args) != 1 || entries[1].Args[0] != "only" {
+		t.Errorf("expected args not to leak between entries, got %v", entries[1].Args)
+	}
+	if _, ok := entries[1].Metadata["ingestedAt"]; ok {
+		t.Error("expected metadata not to leak between entries")
+	}
+}
+
+func TestPools_ResetOnReturn(t *testing.T) {
+	m := getMetadata()
+	m["stale"] = true
+	putMetadata(m)
+	if len(m) != 0 {
+		t.Errorf("expected metadata to be cleared, got %v", m)
+	}
+
+	args := getArgs(3)
+	args[0], args[1], args[2] = "a", "b", "c"
+	putArgs(args)
+	for i, v := range args {
+		if v != nil {
+			t.Errorf("expected arg %d to be cleared, got %v", i, v)
+		}
+	}
+
+	var payloads []string
+	marshalPooled(map[string]int{"a": 1}, &Config{}, func(p []byte) { payloads = append(payloads, string(p)) })
+	marshalPooled(map[string]int{"b": 2}, &Config{}, func(p []byte) { payloads = append(payloads, string(p)) })
+	if payloads[0] != `{"a":1}` || payloads[1] != `{"b":2}` {
+		t.Errorf("expected independent compact payloads, got %v", payloads)
+	}
+}
+
+// benchLogSetup registers a no-op client so log() runs the full broadcast path.
+func benchLogSetup(b *testing.B) {
+	resetInstance()
+	b.Cleanup(resetInstance)
+	getInstance().addClient(&client{send: func([]byte) error { return nil }})
+}
+
+func BenchmarkLog(b *testing.B) {
+	benchLogSetup(b)
+	fields := map[string]interface{}{"status": 200, "path": "/api/data"}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Info("request completed", fields)
+	}
+}
+
+// BenchmarkLog_Unpooled marshals the same entry shape with fresh allocations,
+// as a baseline for BenchmarkLog_Pooled.
+func BenchmarkLog_Unpooled(b *testing.B) {
+	entry := benchEntry()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e := entry
+		e.Args = make([]interface{}, len(entry.Args))
+		copy(e.Args, entry.Args)
+		e.Metadata = make(map[string]interface{}, len(entry.Metadata))
+		for k, v := range entry.Metadata {
+			e.Metadata[k] = v
+		}
+		if _, err := json.Marshal(e); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLog_Pooled(b *testing.B) {
+	entry := benchEntry()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e := entry
+		e.Args = getArgs(len(entry.Args))
+		copy(e.Args, entry.Args)
+		e.Metadata = getMetadata()
+		for k, v := range entry.Metadata {
+			e.Metadata[k] = v
+		}
+		if err := marshalPooled(e, &Config{}, func([]byte) {}); err != nil {
+			b.Fatal(err)
+		}
+		putArgs(e.Args)
+		putMetadata(e.Metadata)
+	}
+}
+
+func benchEntry() LogEntry {
+	return LogEntry{
+		ID:        "abc123",
+		Timestamp: "2024-01-01T00:00:00Z",
+		Level:     INFO,
+		Args:      []interface{}{"request completed", map[string]interface{}{"status": 200}},
+		Metadata: map[string]interface{}{
+			"file":    "main.go",
+			"line":    42,
+			"func":    "main.handler",
+			"lang":    "go",
+			"service": "bench",
+		},
+	}
+}
+
+func TestLog_DynamicLevel(t *testing.T) {
+	read := initCapture(t, Config{})
+
+	for _, ok := range []bool{true, false} {
+		level := INFO
+		if !ok {
+			level = WARN
+		}
+		Log(level, "operation finished", map[string]interface{}{"ok": ok})
+	}
+
+	entries := read()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Level != INFO || entries[1].Level != WARN {
+		t.Errorf("expected INFO then WARN, got %s, %s", entries[0].Level, entries[1].Level)
+	}
+}
+
+func TestLog_InvalidLevel(t *testing.T) {
+	read := initCapture(t, Config{})
+
+	Log(LogLevel("VERBOSE"), "unknown level")
+
+	entries := read()
+	if len(entries) != 1 {
+		t.Fatalf("expected entry to still be emitted, got %d", len(entries))
+	}
+	if entries[0].Level != INFO {
+		t.Errorf("expected unknown level to fall back to INFO, got %s", entries[0].Level)
+	}
+}
+
+func TestLog_SequenceNumbers(t *testing.T) {
+	read := initCapture(t, Config{})
+
+	for i := 0; i < 100; i++ {
+		Info("sequential", i)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				Info("concurrent")
+			}
+		}()
+	}
+	wg.Wait()
+
+	entries := read()
+	if len(entries) != 500 {
+		t.Fatalf("expected 500 entries, got %d", len(entries))
+	}
+
+	// Sequential calls are numbered in call order.
+	for i := 1; i < 100; i++ {
+		if entries[i].Seq <= entries[i-1].Seq {
+			t.Fatalf("expected strictly increasing seq, got %d after %d", entries[i].Seq, entries[i-1].Seq)
+		}
+	}
+
+	// Concurrent calls never share a number.
+	seen := make(map[uint64]bool)
+	for _, e := range entries {
+		if e.Seq == 0 || seen[e.Seq] {
+			t.Fatalf("expected unique non-zero seq, got duplicate or zero %d", e.Seq)
+		}
+		seen[e.Seq] = true
+	}
+}
+
+func TestInit_ProductionSinksWithoutServer(t *testing.T) {
+	resetInstance()
+	t.Cleanup(resetInstance)
+
+	var console bytes.Buffer
+	filePath := filepath.Join(t.TempDir(), "prod.ndjson")
+	Init(Config{IsDev: false, Console: &console, LogFilePath: filePath})
+
+	s := getInstance()
+	if s.server != nil {
+		t.Fatal("expected no server outside dev without EnableServer")
+	}
+
+	Info("prod entry")
+	s.ciWriter.Flush()
+
+	if !strings.Contains(console.String(), "prod entry") {
+		t.Errorf("expected console sink to receive entry, got %q", console.String())
+	}
+	content, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "prod entry") {
+		t.Errorf("expected file sink to receive entry, got %q", content)
+	}
+}
+
+func TestInit_ServerRequiresFlagOutsideDev(t *testing.T) {
+	resetInstance()
+	t.Cleanup(resetInstance)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enabled := true
+	Init(Config{IsDev: false, EnableServer: &enabled, Listener: listener})
+
+	if getInstance().server == nil {
+		t.Fatal("expected EnableServer to start the server outside dev")
+	}
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("expected server to accept connections: %v", err)
+	}
+	conn.Close()
+
+	resetInstance()
+	disabled := false
+	Init(Config{IsDev: true, CIMode: &disabled, EnableServer: &disabled, Console: ioutil.Discard})
+	if getInstance().server != nil {
+		t.Error("expected EnableServer=false to keep the server off in dev")
+	}
+}
+
+// infoHelper and facadeInfo stand in for logging wrappers one and two
+// frames deep.
+func infoHelper(msg string) { LogSkip(1, INFO, msg) }
+
+func facadeInfo(msg string)  { facadeInner(msg) }
+func facadeInner(msg string) { LogSkip(2, INFO, msg) }
+
+func TestLogSkip_AttributesRealCaller(t *testing.T) {
+	read := initCapture(t, Config{})
+
+	_, _, line, _ := runtime.Caller(0)
+	Info("direct")
+	infoHelper("one wrapper")
+	facadeInfo("two wrappers")
+
+	entries := read()
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	for i, e := range entries {
+		wantLine := float64(line + 1 + i)
+		if e.Metadata["file"] != "slogx_test.go" || e.Metadata["line"] != wantLine {
+			t.Errorf("%v: expected slogx_test.go:%v, got %v:%v", e.Args[0], wantLine, e.Metadata["file"], e.Metadata["line"])
+		}
+		if e.Metadata["func"] != "slogx.TestLogSkip_AttributesRealCaller" {
+			t.Errorf("%v: expected the test as caller, got %v", e.Args[0], e.Metadata["func"])
+		}
+	}
+}
+
+func TestSetServiceName(t *testing.T) {
+	read := initCapture(t, Config{ServiceName: "indexer"})
+
+	Info("before")
+	SetServiceName("compactor")
+	if got := ServiceName(); got != "compactor" {
+		t.Errorf("expected ServiceName to report the new name, got %q", got)
+	}
+	Info("after")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			SetServiceName("compactor")
+			Info("concurrent")
+		}()
+	}
+	wg.Wait()
+
+	entries := read()
+	if entries[0].Metadata["service"] != "indexer" {
+		t.Errorf("expected earlier entry to keep the old name, got %v", entries[0].Metadata["service"])
+	}
+	for _, e := range entries[1:] {
+		if e.Metadata["service"] != "compactor" {
+			t.Errorf("expected new name on later entries, got %v", e.Metadata["service"])
+		}
+	}
+
+	SetServiceName("")
+	if got := ServiceName(); got != "go-service" {
+		t.Errorf("expected empty name to restore the default, got %q", got)
+	}
+}
+
+type tenantKey struct{}
+
+func TestServiceNameFunc(t *testing.T) {
+	read := initCapture(t, Config{
+		ServiceName: "worker",
+		ServiceNameFunc: func(ctx context.Context) string {
+			tenant, _ := ctx.Value(tenantKey{}).(string)
+			return tenant
+		},
+	})
+
+	acme := context.WithValue(context.Background(), tenantKey{}, "acme")
+	globex := context.WithValue(context.Background(), tenantKey{}, "globex")
+	LogCtx(acme, INFO, "job started")
+	LogCtx(globex, INFO, "job started")
+	LogCtx(context.Background(), INFO, "no tenant")
+	Info("no context")
+
+	expected := []string{"acme", "globex", "worker", "worker"}
+	entries := read()
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d entries, got %d", len(expected), len(entries))
+	}
+	for i, entry := range entries {
+		if entry.Metadata["service"] != expected[i] {
+			t.Errorf("expected entry %d from %q, got %v", i, expected[i], entry.Metadata["service"])
+		}
+	}
+}
+
+func TestMetadataPrefix(t *testing.T) {
+	read := initCapture(t, Config{MetadataPrefix: "slogx_", ServiceName: "api"})
+	Info("prefixed", At(time.Now()))
+
+	meta := read()[0].Metadata
+	for _, key := range []string{"file", "line", "func", "lang", "service", "ingestedAt"} {
+		if _, ok := meta["slogx_"+key]; !ok {
+			t.Errorf("expected slogx_%s, got %v", key, meta)
+		}
+		if _, ok := meta[key]; ok {
+			t.Errorf("expected unprefixed %s to be absent", key)
+		}
+	}
+	if meta["slogx_service"] != "api" {
+		t.Errorf("expected prefixed service value, got %v", meta["slogx_service"])
+	}
+
+	read = initCapture(t, Config{})
+	Info("plain")
+	if meta := read()[0].Metadata; meta["file"] != "slogx_test.go" || meta["lang"] != "go" {
+		t.Errorf("expected unprefixed keys by default, got %v", meta)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	read := initCapture(t, Config{Version: "v1.4.2", VersionInEntries: true})
+	Info("versioned")
+
+	if got := read()[0].Metadata["version"]; got != "v1.4.2" {
+		t.Errorf("expected explicit version in entry metadata, got %v", got)
+	}
+	var hs handshake
+	if err := json.Unmarshal(getInstance().handshakeFrame(), &hs); err != nil {
+		t.Fatal(err)
+	}
+	if hs.Version != "v1.4.2" {
+		t.Errorf("expected explicit version in the hello frame, got %q", hs.Version)
+	}
+
+	read = initCapture(t, Config{})
+	Info("unversioned")
+	if _, ok := read()[0].Metadata["version"]; ok {
+		t.Error("expected no per-entry version unless VersionInEntries is set")
+	}
+	if got, want := getInstance().version, buildVersion(); got != want {
+		t.Errorf("expected build info fallback %q, got %q", want, got)
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		if buildVersion() != info.Main.Version {
+			t.Errorf("expected the main module version, got %q", buildVersion())
+		}
+	}
+}
+
+func TestMsg(t *testing.T) {
+	read := initCapture(t, Config{})
+
+	_, _, line, _ := runtime.Caller(0)
+	Msg(INFO, "started")
+	Msg(WARN, "slow request", map[string]interface{}{"ms": 900})
+	Msg(INFO, "request done",
+		map[string]interface{}{"status": 200, "path": "/a"},
+		map[string]interface{}{"ms": 12, "path": "/b"},
+	)
+
+	entries := read()
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	if len(entries[0].Args) != 1 || entries[0].Args[0] != "started" {
+		t.Errorf("expected message only, got %v", entries[0].Args)
+	}
+	if entries[1].Level != WARN || entries[1].Args[1].(map[string]interface{})["ms"] != float64(900) {
+		t.Errorf("expected message and fields, got %v", entries[1])
+	}
+	merged := entries[2].Args[1].(map[string]interface{})
+	if len(entries[2].Args) != 2 || merged["status"] != float64(200) || merged["ms"] != float64(12) || merged["path"] != "/b" {
+		t.Errorf("expected one merged object with later maps winning, got %v", entries[2].Args)
+	}
+	for i, e := range entries {
+		if e.Metadata["line"] != float64(line+1+i) {
+			t.Errorf("expected caller line %d, got %v", line+1+i, e.Metadata["line"])
+		}
+	}
+}
+
+func TestOnEntry(t *testing.T) {
+	read := initCapture(t, Config{
+		OnEntry: func(e *LogEntry) bool {
+			switch e.Args[0] {
+			case "healthcheck":
+				return false
+			case "login":
+				e.Args[1] = "[scrubbed]"
+				e.Metadata["tenant"] = "acme"
+			case "bad hook":
+				panic("hook failed")
+			}
+			return true
+		},
+	})
+
+	Info("login", "hunter2")
+	Info("healthcheck")
+	Info("bad hook")
+	Info("plain")
+
+	entries := read()
+	if len(entries) != 3 {
+		t.Fatalf("expected the healthcheck entry dropped, got %d entries", len(entries))
+	}
+	if entries[0].Args[1] != "[scrubbed]" || entries[0].Metadata["tenant"] != "acme" {
+		t.Errorf("expected hook mutations to be sent, got %v", entries[0])
+	}
+	if entries[1].Args[0] != "bad hook" || entries[1].Metadata["hookPanic"] != "hook failed" {
+		t.Errorf("expected a panicking hook to be contained, got %v", entries[1])
+	}
+	if entries[2].Args[0] != "plain" {
+		t.Errorf("expected logging to continue after the panic, got %v", entries[2])
+	}
+}
+
+func stackOuter() { stackInner() }
+func stackInner() { Warn("deep") }
+
+func TestStructuredStack(t *testing.T) {
+	read := initCapture(t, Config{StructuredStack: true})
+
+	stackOuter()
+	Info("shallow")
+
+	entries := read()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	frames := entries[0].StackFrames
+	want := []string{"stackInner", "stackOuter", "TestStructuredStack"}
+	if len(frames) < len(want) {
+		t.Fatalf("expected at least %d frames, got %+v", len(want), frames)
+	}
+	for i, name := range want {
+		if !strings.HasSuffix(frames[i].Function, "."+name) {
+			t.Errorf("frame %d: expected %s, got %s", i, name, frames[i].Function)
+		}
+		if filepath.Base(frames[i].File) != "slogx_test.go" || frames[i].Line == 0 {
+			t.Errorf("frame %d: expected a location in slogx_test.go, got %s:%d", i, frames[i].File, frames[i].Line)
+		}
+	}
+	if !strings.Contains(entries[0].Stacktrace, "stackInner") {
+		t.Errorf("expected the stacktrace string to remain, got %q", entries[0].Stacktrace)
+	}
+	if !strings.HasSuffix(entries[1].StackFrames[0].Function, ".TestStructuredStack") {
+		t.Errorf("expected the test as the innermost frame, got %+v", entries[1].StackFrames[0])
+	}
+}
+
+func TestLog_NonFiniteFloatsStillDelivered(t *testing.T) {
+	read := initCapture(t, Config{})
+
+	Info("ratio", math.NaN(), map[string]float64{"limit": math.Inf(1)})
+
+	entries := read()
+	if len(entries) != 1 {
+		t.Fatalf("expected the entry to be delivered, got %d entries", len(entries))
+	}
+	if entries[0].Args[1] != "NaN" {
+		t.Errorf("expected NaN as a string, got %v", entries[0].Args[1])
+	}
+}
+
+func TestClassifyArgs(t *testing.T) {
+	read := initCapture(t, Config{ClassifyArgs: true})
+
+	Info("upload failed", map[string]interface{}{"size": 42}, errors.New("disk full"), "retrying")
+
+	entries := read()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.Message != "upload failed" {
+		t.Errorf("expected the first string as message, got %q", entry.Message)
+	}
+	expectedData := []interface{}{map[string]interface{}{"size": float64(42)}, "retrying"}
+	if !reflect.DeepEqual(entry.Data, expectedData) {
+		t.Errorf("expected data %v, got %v", expectedData, entry.Data)
+	}
+	if len(entry.Errors) != 1 || entry.Errors[0].(map[string]interface{})["message"] != "disk full" {
+		t.Errorf("expected one error block, got %v", entry.Errors)
+	}
+	if len(entry.Args) != 0 {
+		t.Errorf("expected no flat args, got %v", entry.Args)
+	}
+}
+
+func TestClassifyArgs_KeepFlatArgs(t *testing.T) {
+	read := initCapture(t, Config{ClassifyArgs: true, KeepFlatArgs: true})
+
+	Info(map[string]interface{}{"id": 7}, "saved")
+
+	entry := read()[0]
+	if entry.Message != "saved" || len(entry.Data) != 1 || entry.Errors != nil {
+		t.Errorf("expected message and data classified, got %+v", entry)
+	}
+	if len(entry.Args) != 2 || entry.Args[1] != "saved" {
+		t.Errorf("expected flat args kept, got %v", entry.Args)
+	}
+}
+
+func recurseAndLog(depth int) {
+	if depth == 0 {
+		Info("bottom")
+		return
+	}
+	recurseAndLog(depth - 1)
+}
+
+func TestMaxStackFrames(t *testing.T) {
+	read := initCapture(t, Config{MaxStackFrames: 5, StructuredStack: true})
+
+	recurseAndLog(100)
+
+	entry := read()[0]
+	lines := strings.Split(strings.TrimSuffix(entry.Stacktrace, "\n"), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("expected 5 frames and a marker, got %d lines:\n%s", len(lines), entry.Stacktrace)
+	}
+	marker := lines[5]
+	if !strings.HasPrefix(marker, "… ") || !strings.HasSuffix(marker, " more frames") {
+		t.Errorf("expected a more-frames marker, got %q", marker)
+	}
+	if len(entry.StackFrames) != 6 || entry.StackFrames[5].Function != marker {
+		t.Errorf("expected the structured stack capped the same way, got %+v", entry.StackFrames)
+	}
+	if entry.Metadata["func"] != "slogx.recurseAndLog" {
+		t.Errorf("expected the caller to stay the innermost frame, got %v", entry.Metadata["func"])
+	}
+
+	// At least the 96 remaining recursive calls were cut.
+	var omitted int
+	fmt.Sscanf(marker, "… %d more frames", &omitted)
+	if omitted < 96 {
+		t.Errorf("expected the marker to count the cut frames, got %q", marker)
+	}
+}
+
+type queryError struct{ query string }
+
+func (e *queryError) Error() string { return "query failed: " + e.query }
+
+func TestLog_NilArgs(t *testing.T) {
+	read := initCapture(t, Config{})
+
+	Info()
+	Info(nil)
+	var typedNil *queryError
+	Warn("lookup", nil, typedNil, 3)
+
+	entries := read()
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	if entries[0].Args == nil || len(entries[0].Args) != 0 {
+		t.Errorf("expected an empty args array for no args, got %#v", entries[0].Args)
+	}
+	if len(entries[1].Args) != 1 || entries[1].Args[0] != nil {
+		t.Errorf("expected a single null arg, got %#v", entries[1].Args)
+	}
+	expected := []interface{}{"lookup", nil, nil, float64(3)}
+	if !reflect.DeepEqual(entries[2].Args, expected) {
+		t.Errorf("expected nils kept in place, got %#v", entries[2].Args)
+	}
+	if _, failed := entries[2].Metadata["panic"]; failed {
+		t.Errorf("expected a typed nil error to log as null, got %v", entries[2].Metadata)
+	}
+}
+
+func TestLog_Severity(t *testing.T) {
+	read := initCapture(t, Config{MinLevel: TRACE})
+
+	levels := []LogLevel{TRACE, DEBUG, INFO, WARN, ERROR}
+	for _, level := range levels {
+		Log(level, "check")
+	}
+
+	expected := map[LogLevel]int{TRACE: 5, DEBUG: 10, INFO: 20, WARN: 30, ERROR: 40}
+	entries := read()
+	if len(entries) != len(levels) {
+		t.Fatalf("expected %d entries, got %d", len(levels), len(entries))
+	}
+	for i, entry := range entries {
+		if entry.Level != levels[i] || entry.Severity != expected[levels[i]] {
+			t.Errorf("expected %s to have severity %d, got %s %d", levels[i], expected[levels[i]], entry.Level, entry.Severity)
+		}
+	}
+}
+
+func TestLogCtx_AttributesCaller(t *testing.T) {
+	read := initCapture(t, Config{})
+
+	LogCtx(context.Background(), INFO, "scoped")
+
+	if entry := read()[0]; entry.Metadata["file"] != "slogx_test.go" || entry.Args[0] != "scoped" {
+		t.Errorf("expected the caller's file and only the logged args, got %v %v", entry.Metadata["file"], entry.Args)
+	}
+
+	args := make([]interface{}, 1, 2)
+	args[0] = "spare capacity"
+	LogCtx(context.Background(), INFO, args...)
+	if spare := args[:2][1]; spare != nil {
+		t.Errorf("expected the caller's spare capacity untouched, got %#v", spare)
+	}
+}
+
+func TestMaxArgs(t *testing.T) {
+	read := initCapture(t, Config{MaxArgs: 3})
+
+	items := make([]interface{}, 50)
+	for i := range items {
+		items[i] = i
+	}
+	Info(items...)
+	Info("user", 42, true)
+
+	entries := read()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	expected := []interface{}{float64(0), float64(1), float64(2), "[47 more args]"}
+	if !reflect.DeepEqual(entries[0].Args, expected) {
+		t.Errorf("expected the args cut with a marker, got %#v", entries[0].Args)
+	}
+	if items[3] != 3 {
+		t.Errorf("expected the caller's args left unchanged, got %v", items[3])
+	}
+	if !reflect.DeepEqual(entries[1].Args, []interface{}{"user", float64(42), true}) {
+		t.Errorf("expected args within the cap untouched, got %#v", entries[1].Args)
+	}
+}
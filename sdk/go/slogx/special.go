@@ -0,0 +1,314 @@
+package slogx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// LogGroup nests fields under a name in the logged output. Create one with
+// Group.
+type LogGroup struct {
+	Name   string
+	Fields interface{}
+}
+
+// Group namespaces related fields so the viewer shows them nested, e.g.
+// Info("req done", Group("http", map[string]interface{}{"status": 200})).
+func Group(name string, fields interface{}) LogGroup {
+	return LogGroup{Name: name, Fields: fields}
+}
+
+// RawJSON is an already-encoded JSON document that is embedded in the
+// entry as-is, e.g. Info("got event", RawJSON(body)). Invalid JSON is
+// logged as a string instead.
+type RawJSON []byte
+
+// LockedValue is a value serialized while holding its lock. Create one with
+// Locked.
+type LockedValue struct {
+	Lock  sync.Locker
+	Value interface{}
+}
+
+// Locked logs v while holding l, for maps and structs that other goroutines
+// mutate under that lock. Reading a map during a concurrent write is a
+// fatal runtime error that no recover can catch, so shared data must be
+// logged this way (or copied by its owner first). An *sync.RWMutex is
+// read-locked.
+func Locked(l sync.Locker, v interface{}) LockedValue {
+	return LockedValue{Lock: l, Value: v}
+}
+
+var (
+	lockedValueType = reflect.TypeOf(LockedValue{})
+	syncMapType     = reflect.TypeOf((*sync.Map)(nil))
+	contextType     = reflect.TypeOf((*context.Context)(nil)).Elem()
+	logGroupType    = reflect.TypeOf(LogGroup{})
+	rawJSONType     = reflect.TypeOf(RawJSON(nil))
+	osFileType      = reflect.TypeOf((*os.File)(nil))
+	osProcessType   = reflect.TypeOf((*os.Process)(nil))
+	timeType        = reflect.TypeOf(time.Time{})
+)
+
+// serializeSpecial handles types with a dedicated representation. It is
+// consulted before the generic kind-based serialization, and again after
+// each pointer is dereferenced, so *time.Time or *RawJSON get the same
+// treatment as the values they point to.
+func (s *serializer) serializeSpecial(val reflect.Value) (interface{}, bool) {
+	// A type's own representation wins over the built-in ones.
+	if result, ok := s.serializeLoggable(val); ok {
+		return result, true
+	}
+
+	switch val.Type() {
+	case logGroupType:
+		g := val.Interface().(LogGroup)
+		return map[string]interface{}{
+			g.Name: s.serializeChild(g.Name, reflect.ValueOf(g.Fields)),
+		}, true
+
+	case lockedValueType:
+		lv := val.Interface().(LockedValue)
+		if lv.Lock == nil {
+			return s.serialize(lv.Value), true
+		}
+		l := lv.Lock
+		if rw, ok := l.(interface{ RLocker() sync.Locker }); ok {
+			l = rw.RLocker()
+		}
+		l.Lock()
+		defer l.Unlock()
+		return s.serialize(lv.Value), true
+
+	case syncMapType:
+		if val.IsNil() {
+			return nil, true
+		}
+		return s.serializeSyncMap(val.Interface().(*sync.Map)), true
+
+	case syncMapType.Elem():
+		if !val.CanAddr() {
+			return "<sync.Map>", true
+		}
+		return s.serializeSyncMap(val.Addr().Interface().(*sync.Map)), true
+
+	case rawJSONType:
+		data := val.Bytes()
+		if data == nil {
+			return nil, true
+		}
+		if !json.Valid(data) {
+			return s.sanitizeString(string(data)), true
+		}
+		return json.RawMessage(data), true
+
+	case osFileType:
+		if val.IsNil() {
+			return nil, true
+		}
+		return fileSummary(val.Interface().(*os.File)), true
+
+	case osProcessType:
+		if val.IsNil() {
+			return nil, true
+		}
+		return fmt.Sprintf("<*os.Process pid=%d>", val.Interface().(*os.Process).Pid), true
+
+	case blobRefType:
+		if val.CanInterface() {
+			ref := val.Interface().(BlobRef)
+			return map[string]interface{}{"$blob": ref.ID, "size": ref.Size}, true
+		}
+
+	case timeType:
+		// Reached through an unexported field, the value is read through
+		// its address; its wall/ext/loc internals mean nothing to a reader.
+		if !val.CanInterface() {
+			if !val.CanAddr() {
+				return "<time.Time>", true
+			}
+			val = reflect.NewAt(timeType, unsafe.Pointer(val.UnsafeAddr())).Elem()
+		}
+		return val.Interface().(time.Time).Format(time.RFC3339Nano), true
+	}
+
+	if result, ok := s.serializeHTTP(val); ok {
+		return result, true
+	}
+
+	if isSyncPrimitive(val.Type()) {
+		// An atomic is read through its Load method, which needs its
+		// address; it may sit in an unexported field.
+		if val.Type().PkgPath() == "sync/atomic" && val.CanAddr() {
+			ptr := reflect.NewAt(val.Type(), unsafe.Pointer(val.UnsafeAddr()))
+			if load := ptr.MethodByName("Load"); load.IsValid() && load.Type().NumIn() == 0 {
+				return s.serializeValue(load.Call(nil)[0]), true
+			}
+		}
+		// A lock's state means nothing to a reader, and copying it is a bug.
+		return fmt.Sprintf("<%s>", val.Type()), true
+	}
+
+	if val.Type().Implements(contextType) {
+		if val.Kind() == reflect.Ptr && val.IsNil() {
+			return nil, true
+		}
+		return s.contextSummary(val.Interface().(context.Context)), true
+	}
+
+	if isSQLNull(val.Type()) {
+		if !val.FieldByName("Valid").Bool() {
+			return nil, true
+		}
+		return s.serializeValue(val.Field(0)), true
+	}
+
+	if result, ok := serializeEnum(val); ok {
+		return result, true
+	}
+
+	if result, ok := serializeFlags(val); ok {
+		return result, true
+	}
+
+	if s.summarized(val.Type()) {
+		if val.Kind() == reflect.Ptr && val.IsNil() {
+			return nil, true
+		}
+		return fmt.Sprintf("<%s>", val.Type()), true
+	}
+	return nil, false
+}
+
+// isSQLNull matches the database/sql nullable wrappers (NullString,
+// NullInt64, ..., and the generic Null[T]): a value field followed by a
+// Valid flag.
+func isSQLNull(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || t.PkgPath() != "database/sql" || !strings.HasPrefix(t.Name(), "Null") {
+		return false
+	}
+	if t.NumField() != 2 {
+		return false
+	}
+	valid := t.Field(1)
+	return valid.Name == "Valid" && valid.Type.Kind() == reflect.Bool
+}
+
+// contextSummary describes a context by its deadline, whether it is done
+// (and why), and the values named in ContextKeys, instead of its internals.
+func (s *serializer) contextSummary(ctx context.Context) map[string]interface{} {
+	summary := map[string]interface{}{"done": false}
+	if deadline, ok := ctx.Deadline(); ok {
+		summary["deadline"] = deadline.UTC().Format(time.RFC3339Nano)
+	}
+	select {
+	case <-ctx.Done():
+		summary["done"] = true
+		if err := ctx.Err(); err != nil {
+			summary["err"] = err.Error()
+		}
+	default:
+	}
+
+	if len(s.config.ContextKeys) > 0 {
+		values := make(map[string]interface{})
+		for name, key := range s.config.ContextKeys {
+			if v := ctx.Value(key); v != nil {
+				values[name] = s.serializeValue(reflect.ValueOf(v))
+			}
+		}
+		if len(values) > 0 {
+			summary["values"] = values
+		}
+	}
+	return summary
+}
+
+// serializeSyncMap reads a sync.Map through Range, which is safe under
+// concurrent use, unlike reflecting into its internals.
+func (s *serializer) serializeSyncMap(m *sync.Map) map[string]interface{} {
+	result := make(map[string]interface{})
+	m.Range(func(k, v interface{}) bool {
+		key := s.mapKey(reflect.ValueOf(&k).Elem())
+		result[key] = s.serializeChild(key, reflect.ValueOf(v))
+		return true
+	})
+	return result
+}
+
+// fileSummary describes an open file by name and descriptor. The descriptor
+// is read through SyscallConn because File.Fd switches the file to blocking
+// mode.
+func fileSummary(f *os.File) string {
+	var fd uintptr
+	rc, err := f.SyscallConn()
+	if err == nil {
+		err = rc.Control(func(v uintptr) { fd = v })
+	}
+	if err != nil {
+		return fmt.Sprintf("<*os.File %s closed>", f.Name())
+	}
+	return fmt.Sprintf("<*os.File %s fd=%d>", f.Name(), fd)
+}
+
+// summarized reports whether t, or the type it points to, belongs to a
+// package listed in SummarizePackages.
+func (s *serializer) summarized(t reflect.Type) bool {
+	if len(s.config.SummarizePackages) == 0 {
+		return false
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	pkg := t.PkgPath()
+	if pkg == "" {
+		return false
+	}
+	for _, p := range s.config.SummarizePackages {
+		if base, ok := strings.CutSuffix(p, "/..."); ok {
+			if pkg == base || strings.HasPrefix(pkg, base+"/") {
+				return true
+			}
+		} else if pkg == p {
+			return true
+		}
+	}
+	return false
+}
+
+// isSyncPrimitive reports whether t is one of package sync's or
+// sync/atomic's structs, such as sync.Mutex or atomic.Int64, which must not
+// be copied once used.
+func isSyncPrimitive(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && (t.PkgPath() == "sync" || t.PkgPath() == "sync/atomic")
+}
+
+var holdsLockCache sync.Map // reflect.Type -> bool
+
+// holdsLock reports whether a value of type t contains a sync primitive
+// inline, in itself or a nested struct or array field, so that copying the
+// value would copy the lock. Primitives behind pointers don't count.
+func holdsLock(t reflect.Type) bool {
+	if cached, ok := holdsLockCache.Load(t); ok {
+		return cached.(bool)
+	}
+	held := false
+	switch t.Kind() {
+	case reflect.Struct:
+		held = isSyncPrimitive(t)
+		for i := 0; i < t.NumField() && !held; i++ {
+			held = holdsLock(t.Field(i).Type)
+		}
+	case reflect.Array:
+		held = t.Len() > 0 && holdsLock(t.Elem())
+	}
+	holdsLockCache.Store(t, held)
+	return held
+}
@@ -0,0 +1,462 @@
+package slogx
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGroup_NestsFields(t *testing.T) {
+	result := Serialize(Group("http", map[string]interface{}{"status": 200, "ms": 12}))
+
+	m, ok := result.(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected map, got %T", result)
+	}
+	http, ok := m["http"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected fields nested under http, got %v", m)
+	}
+	if http["status"] != 200 || http["ms"] != 12 {
+		t.Errorf("unexpected group fields %v", http)
+	}
+}
+
+func TestGroup_MultipleGroupsIndependent(t *testing.T) {
+	read := initCapture(t, Config{})
+
+	Info("req done",
+		Group("http", map[string]interface{}{"status": 200, "id": "h1"}),
+		Group("db", map[string]interface{}{"queries": 3, "id": "d1"}),
+	)
+
+	entries := read()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	args := entries[0].Args
+
+	http := args[1].(map[string]interface{})["http"].(map[string]interface{})
+	db := args[2].(map[string]interface{})["db"].(map[string]interface{})
+
+	if http["id"] != "h1" || http["status"] != float64(200) {
+		t.Errorf("unexpected http group %v", http)
+	}
+	if db["id"] != "d1" || db["queries"] != float64(3) {
+		t.Errorf("unexpected db group %v", db)
+	}
+	if _, ok := http["queries"]; ok {
+		t.Error("expected groups not to share fields")
+	}
+}
+
+func TestGroup_Nested(t *testing.T) {
+	result := Serialize(Group("http", Group("request", mixedStruct{Public: "x"}))).(map[string]interface{})
+
+	req := result["http"].(map[string]interface{})["request"].(map[string]interface{})
+	if req["Public"] != "x" {
+		t.Errorf("expected nested group to serialize struct, got %v", req)
+	}
+}
+
+func TestOSTypes_Summarized(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "held")
+	if err != nil {
+		t.Fatal(err)
+	}
+	holder := struct {
+		Out  *os.File
+		proc *os.Process
+	}{Out: f, proc: &os.Process{Pid: 42}}
+
+	result := Serialize(holder).(map[string]interface{})
+	out, ok := result["Out"].(string)
+	if !ok || !strings.HasPrefix(out, "<*os.File "+f.Name()+" fd=") {
+		t.Errorf("expected a file summary, got %v", result["Out"])
+	}
+	if result["proc"] != "<*os.Process pid=42>" {
+		t.Errorf("expected a process summary, got %v", result["proc"])
+	}
+
+	f.Close()
+	if got := Serialize(f); got != "<*os.File "+f.Name()+" closed>" {
+		t.Errorf("expected closed file summary, got %v", got)
+	}
+}
+
+func TestSummarizePackages(t *testing.T) {
+	holder := struct {
+		Client *http.Client
+		Header http.Header
+		URL    url.URL
+	}{Client: &http.Client{}, Header: http.Header{"A": {"b"}}}
+
+	ser := newSerializer(&Config{SummarizePackages: []string{"net/http"}})
+	result := ser.serialize(holder).(map[string]interface{})
+	if result["Client"] != "<*http.Client>" || result["Header"] != "<http.Header>" {
+		t.Errorf("expected net/http values summarized, got %v", result)
+	}
+	if _, ok := result["URL"].(map[string]interface{}); !ok {
+		t.Errorf("expected net/url to serialize normally, got %v", result["URL"])
+	}
+
+	ser = newSerializer(&Config{SummarizePackages: []string{"net/..."}})
+	result = ser.serialize(holder).(map[string]interface{})
+	if result["URL"] != "<url.URL>" {
+		t.Errorf("expected subpackage pattern to match net/url, got %v", result["URL"])
+	}
+}
+
+func TestSQLNull_UnwrapsValue(t *testing.T) {
+	row := struct {
+		Name     sql.NullString
+		Nickname sql.NullString
+		Age      sql.NullInt64
+		Score    sql.NullInt64
+	}{
+		Name: sql.NullString{String: "ada", Valid: true},
+		Age:  sql.NullInt64{Int64: 36, Valid: true},
+	}
+
+	result := Serialize(row).(map[string]interface{})
+	if result["Name"] != "ada" || result["Age"] != int64(36) {
+		t.Errorf("expected valid values unwrapped, got %v", result)
+	}
+	for _, key := range []string{"Nickname", "Score"} {
+		if v, ok := result[key]; !ok || v != nil {
+			t.Errorf("expected invalid %s to be null, got %v", key, v)
+		}
+	}
+}
+
+func TestSpecialTypes_ThroughPointers(t *testing.T) {
+	at := time.Date(2024, 3, 1, 9, 30, 0, 500, time.UTC)
+	payload := []byte("ok")
+	retries := 3
+	event := struct {
+		At          *time.Time
+		Payload     *[]byte
+		Retries     *int
+		Body        *RawJSON
+		NoAt        *time.Time
+		NoPayload   *[]byte
+		NoRetries   *int
+		NoBody      *RawJSON
+		AtByPointer **time.Time
+	}{
+		At:          &at,
+		Payload:     &payload,
+		Retries:     &retries,
+		Body:        &RawJSON{'[', '1', ']'},
+		AtByPointer: func() **time.Time { p := &at; return &p }(),
+	}
+
+	ser := newSerializer(&Config{BytesAsString: true})
+	result := ser.serialize(event).(map[string]interface{})
+	expected := map[string]interface{}{
+		"At":          "2024-03-01T09:30:00.0000005Z",
+		"Payload":     "ok",
+		"Retries":     3,
+		"Body":        json.RawMessage("[1]"),
+		"NoAt":        nil,
+		"NoPayload":   nil,
+		"NoRetries":   nil,
+		"NoBody":      nil,
+		"AtByPointer": "2024-03-01T09:30:00.0000005Z",
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected special handling after dereferencing, got %#v", result)
+	}
+	if result := Serialize(at); result != "2024-03-01T09:30:00.0000005Z" {
+		t.Errorf("expected a time as RFC 3339, got %v", result)
+	}
+}
+
+type scheduledJob struct {
+	Name  string
+	runAt time.Time
+}
+
+func TestSpecialTypes_UnexportedTime(t *testing.T) {
+	job := scheduledJob{Name: "backup", runAt: time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)}
+	want := map[string]interface{}{"Name": "backup", "runAt": "2024-03-01T09:30:00Z"}
+	if got := Serialize(job); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected the unexported time as RFC 3339, got %v", got)
+	}
+
+	// A value still marked as read through an unexported field, as
+	// reflection hands it out, is read through its address.
+	field := reflect.ValueOf(&job).Elem().Field(1)
+	if got := newSerializer(&Config{}).serializeValue(field); got != "2024-03-01T09:30:00Z" {
+		t.Errorf("expected the time as RFC 3339, not its internals, got %v", got)
+	}
+}
+
+func TestRawJSON_EmbeddedVerbatim(t *testing.T) {
+	read := initCapture(t, Config{})
+
+	Info("got event", RawJSON(`{"type":"push","commits":[1,2]}`))
+	Info("bad event", RawJSON(`{"type":`))
+
+	entries := read()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	event, ok := entries[0].Args[1].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected raw JSON as structured data, got %T %v", entries[0].Args[1], entries[0].Args[1])
+	}
+	if event["type"] != "push" || len(event["commits"].([]interface{})) != 2 {
+		t.Errorf("unexpected embedded document %v", event)
+	}
+	if entries[1].Args[1] != `{"type":` {
+		t.Errorf("expected invalid JSON logged as a string, got %v", entries[1].Args[1])
+	}
+}
+
+type account struct {
+	ID       int
+	password string
+}
+
+func (a account) SlogxLog() interface{} { return map[string]interface{}{"id": a.ID} }
+
+type reading struct{ raw string }
+
+func (r reading) SlogxLogE() (interface{}, error) {
+	if r.raw == "" {
+		return nil, errors.New("sensor offline")
+	}
+	return map[string]interface{}{"celsius": r.raw}, nil
+}
+
+// dual implements both; SlogxLogE wins.
+type dual struct{}
+
+func (dual) SlogxLog() interface{}           { return "plain" }
+func (dual) SlogxLogE() (interface{}, error) { return "with error", nil }
+
+type panicky struct{}
+
+func (panicky) SlogxLogE() (interface{}, error) { panic("boom") }
+
+func TestLoggable(t *testing.T) {
+	result := Serialize(struct {
+		Account account
+		Ok      reading
+		Broken  reading
+		Both    dual
+		Panics  panicky
+	}{
+		Account: account{ID: 7, password: "hunter2"},
+		Ok:      reading{raw: "21.5"},
+	}).(map[string]interface{})
+
+	if acc := result["Account"].(map[string]interface{}); acc["id"] != 7 || len(acc) != 1 {
+		t.Errorf("expected SlogxLog representation, got %v", acc)
+	}
+	if ok := result["Ok"].(map[string]interface{}); ok["celsius"] != "21.5" {
+		t.Errorf("expected SlogxLogE value, got %v", ok)
+	}
+	if result["Broken"] != "[log error: sensor offline]" {
+		t.Errorf("expected error placeholder, got %v", result["Broken"])
+	}
+	if result["Both"] != "with error" {
+		t.Errorf("expected SlogxLogE to take precedence, got %v", result["Both"])
+	}
+	if result["Panics"] != "[log error: panic: boom]" {
+		t.Errorf("expected a panicking method to be reported, got %v", result["Panics"])
+	}
+}
+
+type tokenSelf string
+
+func (t tokenSelf) SlogxLog() interface{} { return t }
+
+func TestLoggable_ReturnsItself(t *testing.T) {
+	ser := newSerializer(&Config{MaxDepth: 8})
+	if result := ser.serialize(tokenSelf("abc")); result != maxDepthMarker {
+		t.Errorf("expected a self-returning representation to stop at MaxDepth, got %v", result)
+	}
+	if result := Serialize(map[string]interface{}{"token": tokenSelf("abc")}); result.(map[string]interface{})["token"] != maxDepthMarker {
+		t.Errorf("expected the default MaxDepth to apply, got %v", result)
+	}
+}
+
+func TestLocked_ConcurrentMutation(t *testing.T) {
+	read := initCapture(t, Config{})
+
+	var mu sync.RWMutex
+	sessions := map[string]int{}
+	var registry sync.Map
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			key := strconv.Itoa(i % 50)
+			mu.Lock()
+			sessions[key] = i
+			if i%3 == 0 {
+				delete(sessions, key)
+			}
+			mu.Unlock()
+			registry.Store(key, i)
+		}
+	}()
+
+	for i := 0; i < 200; i++ {
+		Info("sessions", Locked(&mu, sessions), &registry)
+	}
+	close(stop)
+	wg.Wait()
+
+	entries := read()
+	if len(entries) != 200 {
+		t.Fatalf("expected 200 entries, got %d", len(entries))
+	}
+	last := entries[len(entries)-1]
+	if _, ok := last.Args[1].(map[string]interface{}); !ok {
+		t.Errorf("expected the locked map serialized as an object, got %v", last.Args[1])
+	}
+	if _, ok := last.Args[2].(map[string]interface{}); !ok {
+		t.Errorf("expected the sync.Map serialized as an object, got %v", last.Args[2])
+	}
+}
+
+func TestSyncMap_Field(t *testing.T) {
+	holder := &struct{ cache sync.Map }{}
+	holder.cache.Store("a", 1)
+	holder.cache.Store(nil, 2)
+
+	result := Serialize(holder).(map[string]interface{})
+	cache := result["cache"].(map[string]interface{})
+	if cache["a"] != 1 || cache["<nil>"] != 2 || len(cache) != 2 {
+		t.Errorf("expected sync.Map contents, got %v", cache)
+	}
+}
+
+type requestIDKey struct{}
+
+func TestContext_Summarized(t *testing.T) {
+	deadline := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	ctx = context.WithValue(ctx, requestIDKey{}, "req-42")
+
+	ser := newSerializer(&Config{ContextKeys: map[string]interface{}{"requestID": requestIDKey{}, "user": "missing"}})
+	got := ser.serialize(ctx)
+	want := map[string]interface{}{
+		"deadline": "2030-01-02T03:04:05Z",
+		"done":     false,
+		"values":   map[string]interface{}{"requestID": "req-42"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	got = Serialize(struct{ Ctx context.Context }{cancelled}).(map[string]interface{})["Ctx"]
+	want = map[string]interface{}{"done": true, "err": "context canceled"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+type guardedCounter struct {
+	sync.Mutex
+	Hits  int
+	owner string
+}
+
+type counterStats struct {
+	Name    string
+	Counter guardedCounter
+}
+
+func TestSerialize_StructsHoldingLocks(t *testing.T) {
+	// Map values can't be addressed, so the lock holders aren't copied to
+	// read them; only exported fields are logged, the lock as a summary.
+	byValue := Serialize(map[string]interface{}{
+		"counter": map[string]guardedCounter{"a": {Hits: 3, owner: "cache"}},
+		"nested":  map[string]counterStats{"a": {Name: "a", Counter: guardedCounter{Hits: 1}}},
+	})
+	want := map[string]interface{}{
+		"counter": map[string]interface{}{"a": map[string]interface{}{"Mutex": "<sync.Mutex>", "Hits": 3}},
+		"nested": map[string]interface{}{"a": map[string]interface{}{
+			"Name":    "a",
+			"Counter": map[string]interface{}{"Mutex": "<sync.Mutex>", "Hits": 1},
+		}},
+	}
+	if !reflect.DeepEqual(byValue, want) {
+		t.Errorf("expected %v by value, got %v", want, byValue)
+	}
+
+	// Through a pointer nothing is copied, so unexported fields stay, and a
+	// held lock doesn't block logging.
+	counter := &guardedCounter{Hits: 3, owner: "cache"}
+	counter.Lock()
+	defer counter.Unlock()
+	want = map[string]interface{}{"Mutex": "<sync.Mutex>", "Hits": 3, "owner": "cache"}
+	if got := Serialize(counter); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v through a pointer, got %v", want, got)
+	}
+
+}
+
+type atomicStats struct {
+	atomic.Int64
+	Name   string
+	last   atomic.Value
+	latest atomic.Pointer[string]
+}
+
+func TestSerialize_StructsHoldingAtomics(t *testing.T) {
+	// Addressable atomics are read through Load.
+	stats := &atomicStats{Name: "hits"}
+	stats.Store(7)
+	stats.last.Store("ok")
+	latest := "v2"
+	stats.latest.Store(&latest)
+	want := map[string]interface{}{"Int64": int64(7), "Name": "hits", "last": "ok", "latest": "v2"}
+	if got := Serialize(stats); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v through a pointer, got %v", want, got)
+	}
+
+	// A copy would copy the atomic, so only exported fields are logged and
+	// the atomic as a summary.
+	byValue := Serialize(map[string]*atomicStats{"a": stats})
+	if !reflect.DeepEqual(byValue, map[string]interface{}{"a": want}) {
+		t.Errorf("expected %v through a map of pointers, got %v", want, byValue)
+	}
+	if !holdsLock(reflect.TypeOf(atomicStats{})) {
+		t.Error("expected a struct embedding an atomic to count as holding a lock")
+	}
+	got := Serialize(map[string]interface{}{"a": struct {
+		atomic.Int64
+		Name string
+	}{Name: "copy"}})
+	want = map[string]interface{}{"a": map[string]interface{}{"Int64": "<atomic.Int64>", "Name": "copy"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v by value, got %v", want, got)
+	}
+}
@@ -0,0 +1,39 @@
+package slogx
+
+import "time"
+
+// Timing measures an operation started with Timer.
+type Timing struct {
+	name  string
+	start time.Time
+}
+
+// Timer starts timing an operation, e.g.
+//
+//	defer slogx.Timer("load config").Stop()
+func Timer(name string) *Timing {
+	return &Timing{name: name, start: time.Now()}
+}
+
+// Stop logs an INFO "timing" entry with the operation's name and elapsed
+// time, attributed to the code that called Stop, and returns the elapsed
+// time. elapsed is readable text such as "1.5s"; elapsedMs is a number for
+// sorting and filtering.
+func (t *Timing) Stop() time.Duration {
+	return t.stop(1, INFO)
+}
+
+// StopLevel is Stop at the given level.
+func (t *Timing) StopLevel(level LogLevel) time.Duration {
+	return t.stop(1, level)
+}
+
+func (t *Timing) stop(skip int, level LogLevel) time.Duration {
+	elapsed := time.Since(t.start)
+	log(skip, level, "timing", map[string]interface{}{
+		"name":      t.name,
+		"elapsed":   elapsed.String(),
+		"elapsedMs": float64(elapsed) / float64(time.Millisecond),
+	})
+	return elapsed
+}
@@ -0,0 +1,39 @@
+package slogx
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimer(t *testing.T) {
+	read := initCapture(t, Config{})
+
+	timer := Timer("load config")
+	time.Sleep(20 * time.Millisecond)
+	elapsed := timer.Stop()
+	Timer("flush").StopLevel(WARN)
+
+	entries := read()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	entry := entries[0]
+	fields := entry.Args[1].(map[string]interface{})
+	if entry.Args[0] != "timing" || fields["name"] != "load config" || entry.Level != INFO {
+		t.Errorf("expected an INFO timing entry for the operation, got %v", entry.Args)
+	}
+	if ms := fields["elapsedMs"].(float64); ms < 20 || ms > 2000 {
+		t.Errorf("expected about 20ms elapsed, got %vms", ms)
+	}
+	if d, err := time.ParseDuration(fields["elapsed"].(string)); err != nil || d != elapsed {
+		t.Errorf("expected elapsed to match the returned %v, got %v", elapsed, fields["elapsed"])
+	}
+	if entry.Metadata["file"] != "timer_test.go" || entry.Metadata["func"] != "slogx.TestTimer" {
+		t.Errorf("expected the entry attributed to the caller of Stop, got %v %v", entry.Metadata["file"], entry.Metadata["func"])
+	}
+
+	if entries[1].Level != WARN || entries[1].Args[1].(map[string]interface{})["name"] != "flush" {
+		t.Errorf("expected StopLevel to log at its level, got %v %v", entries[1].Level, entries[1].Args)
+	}
+}
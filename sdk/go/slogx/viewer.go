@@ -0,0 +1,44 @@
+package slogx
+
+import (
+	_ "embed"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+//go:embed viewer/index.html
+var viewerHTML string
+
+var viewerTemplate = template.Must(template.New("viewer").Parse(viewerHTML))
+
+// handleViewer serves a minimal log viewer that connects back to this
+// server's WebSocket endpoint. It is only available with Config.EnableViewer.
+func (s *SlogX) handleViewer(w http.ResponseWriter, r *http.Request) {
+	if !s.config.EnableViewer {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	viewerTemplate.Execute(w, struct{ WSURL string }{viewerWebSocketURL(r)})
+}
+
+// viewerWebSocketURL derives the WebSocket endpoint from the viewer request,
+// so the page keeps working when Handler() is mounted under a prefix.
+func viewerWebSocketURL(r *http.Request) string {
+	scheme := "ws"
+	if r.TLS != nil {
+		scheme = "wss"
+	}
+
+	// RequestURI still carries any prefix stripped before reaching us.
+	path := r.URL.Path
+	if u, err := url.ParseRequestURI(r.RequestURI); err == nil {
+		path = u.Path
+	}
+	path = strings.TrimSuffix(path, "viewer")
+
+	return scheme + "://" + r.Host + path
+}
@@ -0,0 +1,77 @@
+package slogx
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestViewer_Served(t *testing.T) {
+	s, srv := startTestServer(t)
+	s.config.EnableViewer = true
+
+	resp, err := http.Get(srv.URL + "/viewer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("expected HTML, got %q", ct)
+	}
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	expected := `const WS_URL = "ws://` + strings.TrimPrefix(srv.URL, "http://") + `/"`
+	if !strings.Contains(string(body), expected) {
+		t.Errorf("expected viewer to reference %s, got:\n%s", expected, body)
+	}
+	if !strings.Contains(string(body), `wireFormat: "json"`) {
+		t.Error("expected the viewer to ask for JSON frames")
+	}
+	if !strings.Contains(string(body), `meta[metaPrefix + "file"]`) {
+		t.Error("expected the viewer to read the location under the handshake's metadata prefix")
+	}
+	if !strings.Contains(string(body), `entry.args || [entry.message`) {
+		t.Error("expected the viewer to render classified entries without args")
+	}
+}
+
+func TestViewer_UnderPrefix(t *testing.T) {
+	s, _ := startTestServer(t)
+	s.config.EnableViewer = true
+
+	mux := http.NewServeMux()
+	mux.Handle("/debug/logs/", http.StripPrefix("/debug/logs", s.handler()))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/debug/logs/viewer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	if !strings.Contains(string(body), `/debug/logs/"`) {
+		t.Errorf("expected viewer to reference the prefixed WebSocket path, got:\n%s", body)
+	}
+}
+
+func TestViewer_DisabledByDefault(t *testing.T) {
+	_, srv := startTestServer(t)
+
+	resp, err := http.Get(srv.URL + "/viewer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 without EnableViewer, got %d", resp.StatusCode)
+	}
+}
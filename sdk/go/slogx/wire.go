@@ -0,0 +1,115 @@
+package slogx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// WireFormat is the encoding of entry frames sent to a WebSocket viewer.
+type WireFormat string
+
+const (
+	// WireJSON sends entries as JSON text frames.
+	WireJSON WireFormat = "json"
+	// WireMsgPack sends entries as MessagePack binary frames, which are
+	// smaller and cheaper for a viewer to decode.
+	WireMsgPack WireFormat = "msgpack"
+)
+
+// wireFormats lists the formats a viewer may ask for in the handshake.
+var wireFormats = []WireFormat{WireJSON, WireMsgPack}
+
+func validWireFormat(f WireFormat) bool {
+	for _, known := range wireFormats {
+		if f == known {
+			return true
+		}
+	}
+	return false
+}
+
+// msgpackFromJSON re-encodes a JSON frame as MessagePack, so an entry is
+// encoded the same way whatever the wire format. Integers stay integers;
+// other numbers become float64.
+func msgpackFromJSON(payload []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	writeMsgpack(&buf, v)
+	return buf.Bytes(), nil
+}
+
+// writeMsgpack encodes a decoded JSON value. Object keys are sorted as
+// strings, numeric ones included.
+func writeMsgpack(buf *bytes.Buffer, v interface{}) {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case bool:
+		if v {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			buf.WriteByte(0xd3)
+			binary.Write(buf, binary.BigEndian, n)
+			return
+		}
+		if n, err := strconv.ParseUint(v.String(), 10, 64); err == nil {
+			buf.WriteByte(0xcf)
+			binary.Write(buf, binary.BigEndian, n)
+			return
+		}
+		f, _ := v.Float64()
+		buf.WriteByte(0xcb)
+		binary.Write(buf, binary.BigEndian, math.Float64bits(f))
+	case string:
+		writeMsgpackHeader(buf, 0xa0, 0xd9, 0xda, 0xdb, 31, len(v))
+		buf.WriteString(v)
+	case []interface{}:
+		writeMsgpackHeader(buf, 0x90, 0, 0xdc, 0xdd, 15, len(v))
+		for _, elem := range v {
+			writeMsgpack(buf, elem)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		writeMsgpackHeader(buf, 0x80, 0, 0xde, 0xdf, 15, len(v))
+		for _, k := range keys {
+			writeMsgpack(buf, k)
+			writeMsgpack(buf, v[k])
+		}
+	}
+}
+
+// writeMsgpackHeader writes the type and length prefix of a string, array
+// or map: the fix form up to fixMax, then 8-bit (strings only), 16-bit and
+// 32-bit lengths.
+func writeMsgpackHeader(buf *bytes.Buffer, fix, code8, code16, code32 byte, fixMax, n int) {
+	switch {
+	case n <= fixMax:
+		buf.WriteByte(fix | byte(n))
+	case code8 != 0 && n <= math.MaxUint8:
+		buf.WriteByte(code8)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(code16)
+		binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(code32)
+		binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+}
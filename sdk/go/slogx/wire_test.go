@@ -0,0 +1,196 @@
+package slogx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// decodeMsgpack reads the subset of MessagePack that writeMsgpack emits,
+// with numbers as float64 so results compare equal to decoded JSON.
+func decodeMsgpack(t *testing.T, r *bytes.Reader) interface{} {
+	t.Helper()
+	code, err := r.ReadByte()
+	if err != nil {
+		t.Fatal(err)
+	}
+	length := func(size int) int {
+		b := make([]byte, size)
+		r.Read(b)
+		switch size {
+		case 1:
+			return int(b[0])
+		case 2:
+			return int(binary.BigEndian.Uint16(b))
+		}
+		return int(binary.BigEndian.Uint32(b))
+	}
+	str := func(n int) string {
+		b := make([]byte, n)
+		r.Read(b)
+		return string(b)
+	}
+	array := func(n int) []interface{} {
+		out := make([]interface{}, n)
+		for i := range out {
+			out[i] = decodeMsgpack(t, r)
+		}
+		return out
+	}
+	object := func(n int) map[string]interface{} {
+		out := make(map[string]interface{}, n)
+		for i := 0; i < n; i++ {
+			key := decodeMsgpack(t, r).(string)
+			out[key] = decodeMsgpack(t, r)
+		}
+		return out
+	}
+
+	switch {
+	case code == 0xc0:
+		return nil
+	case code == 0xc2, code == 0xc3:
+		return code == 0xc3
+	case code == 0xd3:
+		var n int64
+		binary.Read(r, binary.BigEndian, &n)
+		return float64(n)
+	case code == 0xcf:
+		var n uint64
+		binary.Read(r, binary.BigEndian, &n)
+		return float64(n)
+	case code == 0xcb:
+		var bits uint64
+		binary.Read(r, binary.BigEndian, &bits)
+		return math.Float64frombits(bits)
+	case code&0xe0 == 0xa0:
+		return str(int(code & 0x1f))
+	case code == 0xd9:
+		return str(length(1))
+	case code == 0xda:
+		return str(length(2))
+	case code == 0xdb:
+		return str(length(4))
+	case code&0xf0 == 0x90:
+		return array(int(code & 0x0f))
+	case code == 0xdc:
+		return array(length(2))
+	case code&0xf0 == 0x80:
+		return object(int(code & 0x0f))
+	case code == 0xde:
+		return object(length(2))
+	}
+	t.Fatalf("unexpected msgpack code %#x", code)
+	return nil
+}
+
+func TestMsgpackFromJSON(t *testing.T) {
+	long := strings.Repeat("x", 300)
+	many := make([]interface{}, 20)
+	fields := make(map[string]interface{}, 20)
+	for i := range many {
+		many[i] = float64(i)
+		fields[fmt.Sprint("k", i)] = i%2 == 0
+	}
+	value := map[string]interface{}{
+		"short": "hi", "medium": strings.Repeat("y", 40), "long": long,
+		"many": many, "fields": fields, "ratio": 0.25, "neg": float64(-7), "none": nil,
+	}
+	payload, _ := json.Marshal(value)
+
+	packed, err := msgpackFromJSON(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(packed) >= len(payload) {
+		t.Errorf("expected msgpack to be smaller than JSON, got %d vs %d bytes", len(packed), len(payload))
+	}
+	if got := decodeMsgpack(t, bytes.NewReader(packed)); !reflect.DeepEqual(got, value) {
+		t.Errorf("round trip mismatch:\n got %v\nwant %v", got, value)
+	}
+
+	// Integers past MaxInt64 keep every bit instead of rounding to float64.
+	packed, err = msgpackFromJSON([]byte("18446744073709551615"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := append([]byte{0xcf}, bytes.Repeat([]byte{0xff}, 8)...); !bytes.Equal(packed, want) {
+		t.Errorf("expected a uint64, got % x", packed)
+	}
+}
+
+func TestWireFormat_ClientRequestsMsgpack(t *testing.T) {
+	s, srv := startTestServer(t)
+	text := dialClient(t, wsURL(srv))
+	packed := dialClient(t, wsURL(srv))
+	waitForClients(t, s, 2)
+
+	if err := packed.WriteMessage(websocket.TextMessage, []byte(`{"wireFormat":"msgpack"}`)); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		for _, c := range s.snapshotClients() {
+			if c.binary(WireJSON) {
+				return true
+			}
+		}
+		return false
+	})
+
+	Warn("quota", map[string]interface{}{"used": 0.97, "limit": 1000, "tenant": "acme"})
+
+	text.SetReadDeadline(time.Now().Add(2 * time.Second))
+	kind, jsonFrame, err := text.ReadMessage()
+	if err != nil || kind != websocket.TextMessage {
+		t.Fatalf("expected a JSON text frame, got kind %d: %v", kind, err)
+	}
+	packed.SetReadDeadline(time.Now().Add(2 * time.Second))
+	kind, binFrame, err := packed.ReadMessage()
+	if err != nil || kind != websocket.BinaryMessage {
+		t.Fatalf("expected a binary frame, got kind %d: %v", kind, err)
+	}
+
+	var fromJSON interface{}
+	if err := json.Unmarshal(jsonFrame, &fromJSON); err != nil {
+		t.Fatal(err)
+	}
+	if fromMsgpack := decodeMsgpack(t, bytes.NewReader(binFrame)); !reflect.DeepEqual(fromMsgpack, fromJSON) {
+		t.Errorf("expected the same entry in both formats:\nmsgpack %v\njson    %v", fromMsgpack, fromJSON)
+	}
+}
+
+func TestWireFormat_ClientRequestsJSON(t *testing.T) {
+	s, srv := startTestServer(t)
+	s.config.WireFormat = WireMsgPack
+	conn := dialClient(t, wsURL(srv))
+	waitForClients(t, s, 1)
+
+	// What the bundled viewer sends once connected.
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"wireFormat":"json"}`)); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		clients := s.snapshotClients()
+		return len(clients) == 1 && !clients[0].binary(WireMsgPack)
+	})
+
+	Info("for the viewer")
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	kind, data, err := conn.ReadMessage()
+	if err != nil || kind != websocket.TextMessage {
+		t.Fatalf("expected a JSON text frame, got kind %d: %v", kind, err)
+	}
+	var entry LogEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Args[0] != "for the viewer" {
+		t.Errorf("expected the entry as JSON, got %s: %v", data, err)
+	}
+}
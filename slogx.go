@@ -1,15 +1,164 @@
 package slogx
 
-import impl "github.com/binhonglee/slogx/sdk/go/slogx"
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	impl "github.com/binhonglee/slogx/sdk/go/slogx"
+)
 
 type LogLevel = impl.LogLevel
 type Config = impl.Config
 type LogEntry = impl.LogEntry
+type StackFrame = impl.StackFrame
 type SlogX = impl.SlogX
+type Option = impl.Option
+type ConsoleFormat = impl.ConsoleFormat
+type Format = impl.Format
+type FormatFunc = impl.FormatFunc
+type WireFormat = impl.WireFormat
+type EnumStyle = impl.EnumStyle
+type LogGroup = impl.LogGroup
+type RawJSON = impl.RawJSON
+type LockedValue = impl.LockedValue
+type BlobRef = impl.BlobRef
+type Timing = impl.Timing
+type Captured = impl.Captured
+type TestingT = impl.TestingT
+type Loggable = impl.Loggable
+type LoggableE = impl.LoggableE
+
+const (
+	TRACE = impl.TRACE
+	DEBUG = impl.DEBUG
+	INFO  = impl.INFO
+	WARN  = impl.WARN
+	ERROR = impl.ERROR
+)
+
+const (
+	ConsoleJSON   = impl.ConsoleJSON
+	ConsoleText   = impl.ConsoleText
+	ConsoleLogfmt = impl.ConsoleLogfmt
+)
+
+const (
+	FormatNative = impl.FormatNative
+	FormatOTEL   = impl.FormatOTEL
+)
+
+const (
+	EnumName   = impl.EnumName
+	EnumNumber = impl.EnumNumber
+	EnumBoth   = impl.EnumBoth
+)
+
+const (
+	WireJSON    = impl.WireJSON
+	WireMsgPack = impl.WireMsgPack
+)
 
 func Init(config Config) { impl.Init(config) }
+func InitReady(config Config) (addr string, ready <-chan struct{}, err error) {
+	return impl.InitReady(config)
+}
+
+func Handler() http.Handler { return impl.Handler() }
+
+func Shutdown(ctx context.Context) error { return impl.Shutdown(ctx) }
+
+func WaitForClient(ctx context.Context) error { return impl.WaitForClient(ctx) }
+
+func Record(w io.Writer) (stop func()) { return impl.Record(w) }
+func Replay(ctx context.Context, r io.Reader, realtime bool) error {
+	return impl.Replay(ctx, r, realtime)
+}
+
+func Capture() (c *Captured, stop func()) { return impl.Capture() }
+func MarshalDeterministic(entry LogEntry) ([]byte, error) {
+	return impl.MarshalDeterministic(entry)
+}
+
+func SetServiceName(name string) { impl.SetServiceName(name) }
+func ServiceName() string        { return impl.ServiceName() }
+
+func ParseLevel(s string) (LogLevel, error) { return impl.ParseLevel(s) }
+
+func At(t time.Time) Option        { return impl.At(t) }
+func Component(name string) Option { return impl.Component(name) }
+
+func Group(name string, fields interface{}) LogGroup      { return impl.Group(name, fields) }
+func Locked(l sync.Locker, v interface{}) LockedValue     { return impl.Locked(l, v) }
+func Blob(data []byte) BlobRef                            { return impl.Blob(data) }
+func RegisterEnum(t reflect.Type, names map[int64]string) { impl.RegisterEnum(t, names) }
+func RegisterFormat(name string, fn FormatFunc)           { impl.RegisterFormat(name, fn) }
+func RegisterFlags(t reflect.Type, names map[uint64]string) {
+	impl.RegisterFlags(t, names)
+}
+func RegisterEnumStyle(t reflect.Type, names map[int64]string, style EnumStyle) {
+	impl.RegisterEnumStyle(t, names, style)
+}
+
+// The forwarders below add one frame, so they pass an extra skip to keep
+// caller info pointing at the code that called them.
+
+func Log(level LogLevel, args ...interface{}) { impl.LogSkip(1, level, args...) }
+func LogSkip(skip int, level LogLevel, args ...interface{}) {
+	impl.LogSkip(skip+1, level, args...)
+}
+func LogAck(level LogLevel, args ...interface{}) int { return impl.LogAckSkip(1, level, args...) }
+func LogAckSkip(skip int, level LogLevel, args ...interface{}) int {
+	return impl.LogAckSkip(skip+1, level, args...)
+}
+func LogCtx(ctx context.Context, level LogLevel, args ...interface{}) {
+	impl.LogCtxSkip(ctx, 1, level, args...)
+}
+func LogCtxSkip(ctx context.Context, skip int, level LogLevel, args ...interface{}) {
+	impl.LogCtxSkip(ctx, skip+1, level, args...)
+}
+func SkippedWrites() uint64   { return impl.SkippedWrites() }
+func TrimmedPayloads() uint64 { return impl.TrimmedPayloads() }
+func WithLevel(ctx context.Context, level LogLevel) context.Context {
+	return impl.WithLevel(ctx, level)
+}
+func WithChain(ctx context.Context) context.Context { return impl.WithChain(ctx) }
+
+func BeginBurst(ctx context.Context) context.Context { return impl.BeginBurst(ctx) }
+func EndBurst(ctx context.Context) (discarded int)   { return impl.EndBurst(ctx) }
+
+func Msg(level LogLevel, msg string, fields ...map[string]interface{}) {
+	impl.MsgSkip(1, level, msg, fields...)
+}
+func MsgSkip(skip int, level LogLevel, msg string, fields ...map[string]interface{}) {
+	impl.MsgSkip(skip+1, level, msg, fields...)
+}
+
+func Timer(name string) *Timing { return impl.Timer(name) }
+
+func MemStats(level LogLevel)               { impl.MemStatsSkip(1, level) }
+func MemStatsSkip(skip int, level LogLevel) { impl.MemStatsSkip(skip+1, level) }
+
+// Recover and RecoverRepanic call recover themselves: it only stops a panic
+// when called directly by the deferred function.
+func Recover() {
+	if r := recover(); r != nil {
+		impl.LogPanic(r)
+	}
+}
+func RecoverRepanic() {
+	if r := recover(); r != nil {
+		impl.LogPanic(r)
+		panic(r)
+	}
+}
+func LogPanic(r interface{}) { impl.LogPanic(r) }
 
-func Debug(args ...interface{}) { impl.Debug(args...) }
-func Info(args ...interface{})  { impl.Info(args...) }
-func Warn(args ...interface{})  { impl.Warn(args...) }
-func Error(args ...interface{}) { impl.Error(args...) }
+func Trace(args ...interface{}) { impl.LogSkip(1, impl.TRACE, args...) }
+func Debug(args ...interface{}) { impl.LogSkip(1, impl.DEBUG, args...) }
+func Info(args ...interface{})  { impl.LogSkip(1, impl.INFO, args...) }
+func Warn(args ...interface{})  { impl.LogSkip(1, impl.WARN, args...) }
+func Error(args ...interface{}) { impl.LogSkip(1, impl.ERROR, args...) }